@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const ASSET_INDEX_FILE = "index.ndjson"
+
+// DownloadStructureImages and DownloadStructureFiles pick what the asset stage downloads, set
+// with --assets and --structures.
+var (
+	DownloadStructureImages = false
+	DownloadStructureFiles  = false
+)
+
+// structureAssets are the per drug files downloaded by the asset stage, by Asset kind.
+var structureAssets = []struct {
+	Kind      string
+	URL       string
+	Extension string
+	Enabled   *bool
+}{
+	{"structure_svg", STRUCTURE_SVG_URL, ".svg", &DownloadStructureImages},
+	{"structure_thumb_svg", STRUCTURE_THUMB_SVG_URL, ".svg", &DownloadStructureImages},
+	{"structure_mol", STRUCTURE_MOL_URL, ".mol", &DownloadStructureFiles},
+}
+
+// assetContentChecks reject downloads that aren't the file asked for. DrugBank answers some
+// structure downloads that aren't public with its sign in page instead of an error, and a
+// block page or a truncated download mustn't pass for the file either.
+var assetContentChecks = map[string]func([]byte) bool{
+	".mol": isMolfile,
+	".svg": isSVG,
+}
+
+// assetStore is set by runScrape when --assets or --structures is passed, nil otherwise.
+var assetStore *AssetStore
+
+// Asset is a file downloaded for a drug. Path is relative to the assets directory and named
+// after the SHA-256 of the content, so identical files are stored once.
+type Asset struct {
+	Kind    string `json:"kind"`
+	URL     string `json:"url"`
+	Path    string `json:"path,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	Missing bool   `json:"missing,omitempty"` // the server has no such file (404), or the download failed its content check
+}
+
+// AssetStore is a content-addressed directory of downloaded files. Its index.ndjson maps every
+// source URL to the file it was saved as, so re-runs don't download what is already there.
+type AssetStore struct {
+	dir   string
+	mu    sync.Mutex
+	index map[string]Asset
+	file  *os.File
+}
+
+func OpenAssetStore(dir string) (*AssetStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &AssetStore{dir: dir, index: make(map[string]Asset)}
+	if existing, err := os.Open(filepath.Join(dir, ASSET_INDEX_FILE)); err == nil {
+		scanner := bufio.NewScanner(existing)
+		for scanner.Scan() {
+			var asset Asset
+			if err := json.Unmarshal(scanner.Bytes(), &asset); err != nil {
+				log.Printf("🔥 Skipping bad asset index line: %v\n", err)
+				continue
+			}
+			store.index[asset.URL] = asset
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("OpenAssetStore(): failed to read index: %v", err)
+		}
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, ASSET_INDEX_FILE), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	store.file = file
+	return store, nil
+}
+
+// Fetch returns the asset stored for url, downloading it unless the index has it and its file
+// is still on disk. Only 404s are recorded as missing for good, a download that fails its
+// content check is returned as missing but tried again by the next run.
+func (s *AssetStore) Fetch(kind string, url string, extension string) (Asset, error) {
+	s.mu.Lock()
+	asset, known := s.index[url]
+	s.mu.Unlock()
+	if known && (asset.Missing || s.exists(asset)) {
+		return asset, nil
+	}
+
+	asset = Asset{Kind: kind, URL: url}
+	data, err := fetchBytes(url)
+	if errors.Is(err, errPageNotFound) {
+		asset.Missing = true
+		return asset, s.record(asset)
+	} else if err != nil {
+		return asset, err
+	}
+	if check := assetContentChecks[extension]; check != nil && !check(data) {
+		log.Printf("⚠️ %s is not a %s file, skipping it for this run\n", url, extension)
+		asset.Missing = true
+		return asset, nil
+	}
+
+	sum := sha256.Sum256(data)
+	asset.SHA256 = hex.EncodeToString(sum[:])
+	asset.Path = filepath.Join(asset.SHA256[:2], asset.SHA256+extension)
+	asset.Bytes = int64(len(data))
+
+	if !s.exists(asset) {
+		if err := writeFileAtomic(filepath.Join(s.dir, asset.Path), data); err != nil {
+			return asset, err
+		}
+	}
+	return asset, s.record(asset)
+}
+
+func (s *AssetStore) exists(asset Asset) bool {
+	info, err := os.Stat(filepath.Join(s.dir, asset.Path))
+	return err == nil && info.Size() == asset.Bytes
+}
+
+func (s *AssetStore) record(asset Asset) error {
+	line, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index[asset.URL] = asset
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *AssetStore) Close() error {
+	return s.file.Close()
+}
+
+// writeFileAtomic writes through a temporary file so an interrupted run never leaves a
+// truncated file under a content hash.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// handleStructureAssets downloads the structure images and MOL file of the drug when the
+// asset stage is on.
+func handleStructureAssets(page *goquery.Document, json *DrugInfo) error {
+	if assetStore == nil || json.ID == "" {
+		return nil
+	}
+
+	for _, structure := range structureAssets {
+		if !*structure.Enabled {
+			continue
+		}
+		// biologics have no structure file to download
+		if structure.Extension == ".mol" && strings.EqualFold(json.Type, "Biotech") {
+			continue
+		}
+		asset, err := assetStore.Fetch(structure.Kind, fmt.Sprintf(structure.URL, json.ID), structure.Extension)
+		if err != nil {
+			return fmt.Errorf("handleStructureAssets(): %s: %v", structure.Kind, err)
+		}
+		if !asset.Missing {
+			json.Assets = append(json.Assets, asset)
+		}
+	}
+	return nil
+}
+
+// assetPath returns the path of the drug's asset of the given kind, "" if it wasn't downloaded.
+func assetPath(drugInfo DrugInfo, kind string) string {
+	for _, asset := range drugInfo.Assets {
+		if asset.Kind == kind {
+			return asset.Path
+		}
+	}
+	return ""
+}
+
+// isMolfile reports whether data holds an MDL molfile or SD record, which end their atom
+// block with "M  END".
+func isMolfile(data []byte) bool {
+	return bytes.Contains(data, []byte("M  END"))
+}
+
+// isSVG reports whether data holds a whole SVG document, from its svg element to the closing tag.
+func isSVG(data []byte) bool {
+	start := bytes.Index(data, []byte("<svg"))
+	return start >= 0 && bytes.Contains(data[start:], []byte("</svg>"))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAssetStoreFetch(t *testing.T) {
+	delay, errorDelay, rateLimiter := DelayBetweenRequests, DelayAfterError, limiter
+	DelayBetweenRequests, DelayAfterError, limiter = time.Millisecond, 2*time.Millisecond, NewRateLimiter(1000, 100)
+	defer func() { DelayBetweenRequests, DelayAfterError, limiter = delay, errorDelay, rateLimiter }()
+
+	molfile := "DB00001\n\n\n  0  0  0  0  0  0  0  0  0  0999 V2000\nM  END\n"
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/a.mol", "/copy.mol":
+			fmt.Fprint(w, molfile)
+		case "/login.mol", "/login.svg":
+			fmt.Fprint(w, "<html>Sign in</html>")
+		case "/a.svg":
+			fmt.Fprint(w, `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><path d="M0 0"/></svg>`)
+		case "/truncated.svg":
+			fmt.Fprint(w, `<svg xmlns="http://www.w3.org/2000/svg"><path d=`)
+		case "/challenge.mol":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := OpenAssetStore(dir)
+	if err != nil {
+		t.Fatalf("OpenAssetStore(): %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantMissing bool
+		wantErr     bool
+	}{
+		{"/a.mol", false, false},
+		{"/copy.mol", false, false},
+		{"/login.mol", true, false},
+		{"/gone.mol", true, false},
+		{"/challenge.mol", false, true},
+		{"/a.svg", false, false},
+		{"/login.svg", true, false},
+		{"/truncated.svg", true, false},
+	}
+	assets := make(map[string]Asset)
+	for _, tt := range tests {
+		asset, err := store.Fetch("structure", server.URL+tt.path, filepath.Ext(tt.path))
+		if (err != nil) != tt.wantErr || asset.Missing != tt.wantMissing {
+			t.Errorf("Fetch(%s) = missing %v, error %v, want missing %v, error %v", tt.path, asset.Missing, err, tt.wantMissing, tt.wantErr)
+		}
+		assets[tt.path] = asset
+	}
+	if assets["/a.mol"].Path == "" || assets["/a.mol"].Path != assets["/copy.mol"].Path {
+		t.Errorf("identical files stored as %q and %q", assets["/a.mol"].Path, assets["/copy.mol"].Path)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new session only requests what wasn't settled: 403s and failed content checks are tried again
+	store, err = OpenAssetStore(dir)
+	if err != nil {
+		t.Fatalf("OpenAssetStore(): %v", err)
+	}
+	defer store.Close()
+	before := fmt.Sprint(hits)
+	for _, tt := range tests {
+		store.Fetch("structure", server.URL+tt.path, filepath.Ext(tt.path))
+	}
+	want := map[string]int{"/a.mol": 1, "/copy.mol": 1, "/gone.mol": 1, "/a.svg": 1, "/login.mol": 2, "/login.svg": 2, "/truncated.svg": 2}
+	for path, n := range want {
+		if hits[path] != n {
+			t.Errorf("%s requested %d times over two sessions, want %d (before the second session: %s)", path, hits[path], n, before)
+		}
+	}
+	if hits["/challenge.mol"] != 2*RetryLimit {
+		t.Errorf("/challenge.mol requested %d times, want %d", hits["/challenge.mol"], 2*RetryLimit)
+	}
+}
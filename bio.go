@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	BIO_ENTITY_URL  = "https://go.drugbank.com/bio_entities/%s"
+	POLYPEPTIDE_URL = "https://go.drugbank.com/polypeptides/%s"
+)
+
+// "BE0000048", the polypeptide pages are keyed by UniProt accession instead
+var bioEntityIDRegexp = regexp.MustCompile(`^BE[0-9]+$`)
+
+// FollowBioLinks makes handleBioInteractors fetch the linked BE and polypeptide pages to fill in
+// gene names and UniProt IDs missing from the drug page, set with --follow-bio.
+var FollowBioLinks = false
+
+// bioSections maps the section ids of a drug page to the BioInteractor kind they list.
+var bioSections = []struct {
+	ID   string
+	Kind string
+}{
+	{"targets", "target"},
+	{"enzymes", "enzyme"},
+	{"carriers", "carrier"},
+	{"transporters", "transporter"},
+}
+
+type BioInteractor struct {
+	Kind             string   `json:"kind"`
+	ID               string   `json:"id,omitempty"` // BE-ID, e.g. BE0000048
+	Name             string   `json:"name"`
+	Type             string   `json:"type,omitempty"` // e.g. "Protein", "Protein group"
+	Organism         string   `json:"organism,omitempty"`
+	KnownAction      string   `json:"known_action,omitempty"` // "yes", "no" or "unknown"
+	Actions          []string `json:"actions,omitempty"`
+	GeneName         string   `json:"gene_name,omitempty"`
+	UniProtID        string   `json:"uniprot_id,omitempty"`
+	UniProtName      string   `json:"uniprot_name,omitempty"`
+	GeneralFunction  string   `json:"general_function,omitempty"`
+	SpecificFunction string   `json:"specific_function,omitempty"`
+	References       []string `json:"references,omitempty"`
+	Link             string   `json:"link,omitempty"`
+	PolypeptideLink  string   `json:"polypeptide_link,omitempty"`
+}
+
+// bioEntityCache holds the gene name and UniProt ID found on followed BE and polypeptide
+// pages, most targets are shared by many drugs.
+var bioEntityCache sync.Map
+
+type bioEntityDetails struct {
+	GeneName  string
+	UniProtID string
+}
+
+// definitionList maps the normalized dt titles of a dl to their dd.
+func definitionList(dl *goquery.Selection) map[string]*goquery.Selection {
+	entries := make(map[string]*goquery.Selection)
+	dl.ChildrenFiltered("dt").Each(func(_ int, dt *goquery.Selection) {
+		entries[normalize(dt.Text())] = dt.NextFiltered("dd")
+	})
+	return entries
+}
+
+func handleBioInteractors(page *goquery.Document, json *DrugInfo) error {
+	for _, section := range bioSections {
+		page.Find(fmt.Sprintf("#%s .bond", section.ID)).Each(func(_ int, card *goquery.Selection) {
+			interactor := parseBioInteractorCard(card, section.Kind)
+			if FollowBioLinks && (interactor.GeneName == "" || interactor.UniProtID == "") {
+				followBioLinks(&interactor)
+			}
+			json.BioInteractors = append(json.BioInteractors, interactor)
+		})
+	}
+	return nil
+}
+
+func parseBioInteractorCard(card *goquery.Selection, kind string) BioInteractor {
+	interactor := BioInteractor{Kind: kind}
+
+	// the header links the BE page, or the polypeptide page of single protein entities
+	header := card.Find(".card-header a").First()
+	interactor.Name = strings.TrimSpace(header.Text())
+	if href, exists := header.Attr("href"); exists {
+		switch {
+		case strings.HasPrefix(href, "/bio_entities/"):
+			interactor.Link = "https://go.drugbank.com" + href
+			if id := href[strings.LastIndex(href, "/")+1:]; bioEntityIDRegexp.MatchString(id) {
+				interactor.ID = id
+			}
+		case strings.HasPrefix(href, "/polypeptides/"):
+			interactor.PolypeptideLink = "https://go.drugbank.com" + href
+		}
+	}
+	if id, exists := card.Attr("id"); exists && bioEntityIDRegexp.MatchString(id) {
+		interactor.ID = id
+	}
+	if interactor.Link == "" && interactor.ID != "" {
+		interactor.Link = fmt.Sprintf(BIO_ENTITY_URL, interactor.ID)
+	}
+
+	entries := definitionList(card.Find("dl").First())
+	text := func(title string) string {
+		if dd, exists := entries[title]; exists {
+			value := normalizeFieldValue("", dd.Text())
+			if !strings.EqualFold(value, "not available") {
+				return value
+			}
+		}
+		return ""
+	}
+
+	interactor.Type = text("kind")
+	interactor.Organism = text("organism")
+	interactor.KnownAction = strings.ToLower(text("pharmacological action"))
+	interactor.GeneName = text("gene name")
+	interactor.UniProtName = text("uniprot name")
+	interactor.GeneralFunction = text("general function")
+	interactor.SpecificFunction = text("specific function")
+
+	if dd, exists := entries["actions"]; exists {
+		dd.Find(".badge").Each(func(_ int, badge *goquery.Selection) {
+			interactor.Actions = append(interactor.Actions, normalize(badge.Text()))
+		})
+	}
+	if dd, exists := entries["uniprot id"]; exists {
+		interactor.UniProtID = strings.TrimSpace(dd.Text())
+		if href, exists := dd.Find("a").Attr("href"); exists {
+			interactor.PolypeptideLink = "https://go.drugbank.com" + href
+		}
+	}
+	if dd, exists := entries["references"]; exists {
+		dd.Find("li").Each(func(_ int, li *goquery.Selection) {
+			interactor.References = append(interactor.References, normalizeFieldValue("", li.Text()))
+		})
+	}
+	return interactor
+}
+
+// followBioLinks fills in the gene name and UniProt ID from the BE page, or from the
+// polypeptide page it links to.
+func followBioLinks(interactor *BioInteractor) {
+	// entities without a BE-ID only have their polypeptide page
+	key := interactor.ID
+	if key == "" {
+		key = interactor.PolypeptideLink
+	}
+	if key == "" {
+		return
+	}
+	if cached, exists := bioEntityCache.Load(key); exists {
+		interactor.fillDetails(cached.(bioEntityDetails))
+		return
+	}
+
+	// a failed fetch isn't cached, so a passing error doesn't blank the entity for the whole run
+	failed := false
+	details := bioEntityDetails{}
+	if interactor.ID != "" {
+		if _, page, err := fetchPage(fmt.Sprintf(BIO_ENTITY_URL, interactor.ID)); err == nil {
+			details.readFrom(page)
+			if href, exists := page.Find(`a[href^="/polypeptides/"]`).First().Attr("href"); exists && interactor.PolypeptideLink == "" {
+				interactor.PolypeptideLink = "https://go.drugbank.com" + href
+			}
+		} else {
+			failed = !errors.Is(err, errPageNotFound)
+			log.Printf("🔥 Error following %s: %v\n", interactor.ID, err)
+		}
+	}
+
+	if (details.GeneName == "" || details.UniProtID == "") && interactor.PolypeptideLink != "" {
+		if _, page, err := fetchPage(interactor.PolypeptideLink); err == nil {
+			details.readFrom(page)
+		} else {
+			failed = failed || !errors.Is(err, errPageNotFound)
+			log.Printf("🔥 Error following %s: %v\n", interactor.PolypeptideLink, err)
+		}
+	}
+
+	if !failed {
+		bioEntityCache.Store(key, details)
+	}
+	interactor.fillDetails(details)
+}
+
+// readFrom takes the first gene name and UniProt ID listed on a BE or polypeptide page.
+func (details *bioEntityDetails) readFrom(page *goquery.Document) {
+	page.Find("dl").Each(func(_ int, dl *goquery.Selection) {
+		entries := definitionList(dl)
+		if dd, exists := entries["gene name"]; exists && details.GeneName == "" {
+			details.GeneName = strings.TrimSpace(dd.Text())
+		}
+		for _, title := range []string{"uniprot id", "uniprot accession"} {
+			if dd, exists := entries[title]; exists && details.UniProtID == "" {
+				details.UniProtID = strings.TrimSpace(dd.Text())
+			}
+		}
+	})
+}
+
+func (interactor *BioInteractor) fillDetails(details bioEntityDetails) {
+	if interactor.GeneName == "" {
+		interactor.GeneName = details.GeneName
+	}
+	if interactor.UniProtID == "" {
+		interactor.UniProtID = details.UniProtID
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBioInteractorCard(t *testing.T) {
+	page := fixture(t, `<div id="targets">
+		<div class="bond card" id="BE0000048">
+			<div class="card-header"><a href="/bio_entities/BE0000048">Prothrombin</a></div>
+			<div class="card-body"><dl>
+				<dt>Kind</dt><dd>Protein</dd>
+				<dt>Organism</dt><dd>Humans</dd>
+				<dt>Pharmacological action</dt><dd>Yes</dd>
+				<dt>Actions</dt><dd><div class="badge">Inhibitor</div><div class="badge">Binder</div></dd>
+				<dt>Gene Name</dt><dd>F2</dd>
+				<dt>Uniprot ID</dt><dd><a href="/polypeptides/P00734">P00734</a></dd>
+				<dt>Uniprot Name</dt><dd>Prothrombin</dd>
+				<dt>General Function</dt><dd>Not Available</dd>
+				<dt>References</dt><dd><ol><li>Smith J: Thrombin.</li></ol></dd>
+			</dl></div>
+		</div>
+		<div class="bond card" id="target-2">
+			<div class="card-header"><a href="/polypeptides/P23219">Prostaglandin G/H synthase 1</a></div>
+			<div class="card-body"><dl><dt>Kind</dt><dd>Protein</dd></dl></div>
+		</div>
+		<div class="bond card" id="P35354">
+			<div class="card-header"><a href="/bio_entities/BE0000017">Prostaglandin G/H synthase 2</a></div>
+		</div>
+	</div>`)
+
+	var drugInfo DrugInfo
+	if err := handleBioInteractors(page, &drugInfo); err != nil {
+		t.Fatal(err)
+	}
+	if len(drugInfo.BioInteractors) != 3 {
+		t.Fatalf("got %d interactors, want 3", len(drugInfo.BioInteractors))
+	}
+
+	got := drugInfo.BioInteractors[0]
+	if got.Kind != "target" || got.ID != "BE0000048" || got.Name != "Prothrombin" || got.Type != "Protein" || got.Organism != "Humans" ||
+		got.KnownAction != "yes" || got.GeneName != "F2" || got.UniProtID != "P00734" || got.UniProtName != "Prothrombin" || got.GeneralFunction != "" ||
+		got.Link != "https://go.drugbank.com/bio_entities/BE0000048" || got.PolypeptideLink != "https://go.drugbank.com/polypeptides/P00734" {
+		t.Errorf("first interactor = %+v", got)
+	}
+	if !slices.Equal(got.Actions, []string{"inhibitor", "binder"}) || !slices.Equal(got.References, []string{"Smith J: Thrombin."}) {
+		t.Errorf("actions %q, references %q", got.Actions, got.References)
+	}
+
+	// a polypeptide link names a UniProt accession, not a BE-ID
+	if got := drugInfo.BioInteractors[1]; got.ID != "" || got.Link != "" || got.PolypeptideLink != "https://go.drugbank.com/polypeptides/P23219" {
+		t.Errorf("polypeptide card = ID %q, link %q, polypeptide link %q", got.ID, got.Link, got.PolypeptideLink)
+	}
+	if got := drugInfo.BioInteractors[2]; got.ID != "BE0000017" {
+		t.Errorf("card with a non BE id = ID %q, want BE0000017", got.ID)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// FollowCategoryLinks makes handleDrugCategories fetch each category page once for its MeSH ID,
+// set with --follow-categories.
+var FollowCategoryLinks = false
+
+// ATC_LEVEL_NAMES are the five levels of the Anatomical Therapeutic Chemical classification.
+var ATC_LEVEL_NAMES = [5]string{
+	"anatomical main group",
+	"therapeutic subgroup",
+	"pharmacological subgroup",
+	"chemical subgroup",
+	"chemical substance",
+}
+
+// DrugCategory is one of the drug's categories, ID is the DrugBank category ID (DBCAT000012).
+type DrugCategory struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	MeshID string `json:"mesh_id,omitempty"`
+	Link   string `json:"link,omitempty"`
+}
+
+// ATCLevel is one level of an ATC code, e.g. {2, "N02", "ANALGESICS"}.
+type ATCLevel struct {
+	Level int    `json:"level"`
+	Code  string `json:"code"`
+	Name  string `json:"name,omitempty"`
+}
+
+// ATCCode is a full level 5 code with the names of the groups above it, Levels[0] is the
+// anatomical main group and Levels[4] the chemical substance.
+type ATCCode struct {
+	Code   string     `json:"code"`
+	Name   string     `json:"name,omitempty"`
+	Levels []ATCLevel `json:"levels"`
+}
+
+var (
+	// "N02BA01 — Acetylsalicylic acid", DrugBank uses an em dash but older pages a hyphen
+	atcCodeRegexp = regexp.MustCompile(`^\s*([A-Z](?:[0-9]{2}(?:[A-Z](?:[A-Z](?:[0-9]{2})?)?)?)?)\b\s*(?:[—–-]\s*(.*?))?\s*$`)
+	meshIDRegexp  = regexp.MustCompile(`\b[DC][0-9]{6,9}\b`)
+)
+
+// atcLevelOf returns the ATC level of a code from its length, 0 when it isn't one.
+func atcLevelOf(code string) int {
+	switch len(code) {
+	case 1:
+		return 1
+	case 3:
+		return 2
+	case 4:
+		return 3
+	case 5:
+		return 4
+	case 7:
+		return 5
+	}
+	return 0
+}
+
+// parseATCLine reads the code and name out of "N02BA — Salicylic acid and derivatives".
+func parseATCLine(line string) (ATCLevel, bool) {
+	m := atcCodeRegexp.FindStringSubmatch(normalizeFieldValue("", line))
+	if m == nil || atcLevelOf(m[1]) == 0 {
+		return ATCLevel{}, false
+	}
+	return ATCLevel{Level: atcLevelOf(m[1]), Code: m[1], Name: m[2]}, true
+}
+
+// newATCCode builds the hierarchy of a code from the levels listed with it, levels missing from
+// the page are still derived from the code so a level 5 code always has all five. Shorter codes
+// only get the levels down to their own.
+func newATCCode(code string, name string, listed []ATCLevel) ATCCode {
+	atc := ATCCode{Code: code, Name: name}
+	names := make(map[string]string)
+	for _, level := range listed {
+		names[level.Code] = level.Name
+	}
+	if name != "" {
+		names[code] = name
+	}
+	for _, length := range []int{1, 3, 4, 5, 7} {
+		if length > len(code) {
+			break
+		}
+		prefix := code[:length]
+		atc.Levels = append(atc.Levels, ATCLevel{Level: atcLevelOf(prefix), Code: prefix, Name: names[prefix]})
+	}
+	return atc
+}
+
+func handleATCCodes(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleATCCodes(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleATCCodes(): invalid arguments")
+	}
+
+	// every level 5 code sits in its own .atc-drug block followed by a list of its parent groups
+	entries := sibling.Find(".atc-drug")
+	if entries.Length() == 0 {
+		entries = sibling
+	}
+
+	entries.Each(func(_ int, entry *goquery.Selection) {
+		var listed []ATCLevel
+		entry.Find("li").Each(func(_ int, li *goquery.Selection) {
+			if level, ok := parseATCLine(li.Text()); ok {
+				listed = append(listed, level)
+			}
+		})
+
+		// the substance itself is the text before the list
+		head := entry.Clone()
+		head.Find("ul, ol").Remove()
+		substance, ok := parseATCLine(head.Text())
+		if !ok || substance.Level != 5 {
+			// fall back to the most specific code in the list
+			sort.Slice(listed, func(i, j int) bool { return listed[i].Level > listed[j].Level })
+			if len(listed) == 0 || listed[0].Level != 5 {
+				log.Printf("🔥 %s: no ATC code in %q\n", json.ID, normalizeFieldValue("", entry.Text()))
+				return
+			}
+			substance = listed[0]
+		}
+		json.ATCCodes = append(json.ATCCodes, newATCCode(substance.Code, substance.Name, listed))
+	})
+	return nil
+}
+
+// categoryCache holds the MeSH ID found on each followed category page, by category ID.
+var categoryCache sync.Map
+
+func handleDrugCategories(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleDrugCategories(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleDrugCategories(): invalid arguments")
+	}
+
+	sibling.Find("li").Each(func(_ int, li *goquery.Selection) {
+		category := DrugCategory{Name: normalizeFieldValue("Categories", li.Text())}
+		if href, exists := li.Find("a").First().Attr("href"); exists {
+			category.Link = "https://go.drugbank.com" + href
+			category.ID = href[strings.LastIndex(href, "/")+1:]
+		}
+		if FollowCategoryLinks && category.ID != "" {
+			category.MeshID = categoryMeshID(category)
+		}
+		json.DrugCategories = append(json.DrugCategories, category)
+		// the plain names are kept in Categories for older consumers
+		json.Categories = append(json.Categories, category.Name)
+	})
+	return nil
+}
+
+// categoryMeshID fetches the category page for the MeSH ID it lists, caching it per category.
+func categoryMeshID(category DrugCategory) string {
+	if cached, exists := categoryCache.Load(category.ID); exists {
+		return cached.(string)
+	}
+
+	_, page, err := fetchPage(category.Link)
+	if err != nil {
+		log.Printf("🔥 Error following %s: %v\n", category.ID, err)
+		return ""
+	}
+
+	meshID := ""
+	page.Find("dt").EachWithBreak(func(_ int, dt *goquery.Selection) bool {
+		if strings.HasPrefix(normalize(dt.Text()), "mesh") {
+			meshID = meshIDRegexp.FindString(dt.Next().Text())
+		}
+		return meshID == ""
+	})
+	if meshID == "" {
+		// otherwise take it from the link to the MeSH browser
+		page.Find(`a[href*="nlm.nih.gov"]`).EachWithBreak(func(_ int, a *goquery.Selection) bool {
+			href, _ := a.Attr("href")
+			meshID = meshIDRegexp.FindString(href)
+			return meshID == ""
+		})
+	}
+
+	categoryCache.Store(category.ID, meshID)
+	return meshID
+}
+
+// atcCodeList lists the level 5 ATC codes of a drug.
+func atcCodeList(drugInfo DrugInfo) []string {
+	codes := make([]string, 0, len(drugInfo.ATCCodes))
+	for _, atc := range drugInfo.ATCCodes {
+		codes = append(codes, atc.Code)
+	}
+	return codes
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseATCLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   ATCLevel
+		wantOK bool
+	}{
+		{"N02BA01 — Acetylsalicylic acid", ATCLevel{5, "N02BA01", "Acetylsalicylic acid"}, true},
+		{" N02BA – Salicylic acid and derivatives ", ATCLevel{4, "N02BA", "Salicylic acid and derivatives"}, true},
+		{"N02B - Other analgesics and antipyretics", ATCLevel{3, "N02B", "Other analgesics and antipyretics"}, true},
+		{"N02\n  —  ANALGESICS", ATCLevel{2, "N02", "ANALGESICS"}, true},
+		{"N — Nervous system", ATCLevel{1, "N", "Nervous system"}, true},
+		{"B01AC06", ATCLevel{5, "B01AC06", ""}, true},
+		{"N02BA0 — truncated", ATCLevel{}, false},
+		{"Acetylsalicylic acid", ATCLevel{}, false},
+		{"", ATCLevel{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseATCLine(tt.line)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseATCLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNewATCCode(t *testing.T) {
+	listed := []ATCLevel{{4, "N02BA", "Salicylic acid and derivatives"}, {2, "N02", "ANALGESICS"}}
+	tests := []struct {
+		code string
+		want []ATCLevel
+	}{
+		{"N02BA01", []ATCLevel{{1, "N", ""}, {2, "N02", "ANALGESICS"}, {3, "N02B", ""}, {4, "N02BA", "Salicylic acid and derivatives"}, {5, "N02BA01", "Acetylsalicylic acid"}}},
+		{"N02BA", []ATCLevel{{1, "N", ""}, {2, "N02", "ANALGESICS"}, {3, "N02B", ""}, {4, "N02BA", "Acetylsalicylic acid"}}},
+		{"N02", []ATCLevel{{1, "N", ""}, {2, "N02", "Acetylsalicylic acid"}}},
+		{"N", []ATCLevel{{1, "N", "Acetylsalicylic acid"}}},
+	}
+	for _, tt := range tests {
+		got := newATCCode(tt.code, "Acetylsalicylic acid", listed)
+		if got.Code != tt.code || !slices.Equal(got.Levels, tt.want) {
+			t.Errorf("newATCCode(%q).Levels = %+v, want %+v", tt.code, got.Levels, tt.want)
+		}
+	}
+}
+
+func TestHandleATCCodes(t *testing.T) {
+	page := fixture(t, `<dl><dt>ATC Codes</dt><dd>
+		<div class="atc-drug">N02BA01 — Acetylsalicylic acid<ul>
+			<li>N02BA — Salicylic acid and derivatives</li><li>N02B — Other analgesics and antipyretics</li>
+			<li>N02 — ANALGESICS</li><li>N — NERVOUS SYSTEM</li></ul></div>
+		<div class="atc-drug">Aspirin combinations<ul><li>B01AC56 — Acetylsalicylic acid, combinations with proton pump inhibitors</li><li>B01AC — Platelet aggregation inhibitors excl. heparin</li></ul></div>
+		<div class="atc-drug">no code at all</div>
+	</dd></dl>`)
+
+	var drugInfo DrugInfo
+	if err := handleATCCodes(page.Find("dd"), &drugInfo, "AtcCodes"); err != nil {
+		t.Fatal(err)
+	}
+	if codes := atcCodeList(drugInfo); !slices.Equal(codes, []string{"N02BA01", "B01AC56"}) {
+		t.Fatalf("codes = %q, want [N02BA01 B01AC56]", codes)
+	}
+	if got := drugInfo.ATCCodes[0].Levels[2]; got != (ATCLevel{3, "N02B", "Other analgesics and antipyretics"}) {
+		t.Errorf("level 3 of N02BA01 = %+v", got)
+	}
+	if got := drugInfo.ATCCodes[1]; got.Name != "Acetylsalicylic acid, combinations with proton pump inhibitors" || got.Levels[3].Name != "Platelet aggregation inhibitors excl. heparin" {
+		t.Errorf("B01AC56 = %+v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	JOURNAL_FILE  = "journal.ndjson"
+	MANIFEST_FILE = "run.json"
+)
+
+// RunManifest records the work a run was started with, so --resume can pick it up again.
+type RunManifest struct {
+	Created int64         `json:"created"`
+	Filter  ListingFilter `json:"filter"` // the listing the pages are numbered in
+	Pages   []int         `json:"pages,omitempty"`
+	Links   []DrugLink    `json:"links,omitempty"`
+}
+
+// JournalEntry is one line of the checkpoint journal. Listing pages are stored with
+// the links found on them, drugs by their drug page link; their data lives in the run's sinks.
+type JournalEntry struct {
+	Kind  string     `json:"kind"`
+	Page  int        `json:"page,omitempty"`
+	Links []DrugLink `json:"links,omitempty"`
+	Link  string     `json:"link,omitempty"`
+	ID    string     `json:"id,omitempty"`
+}
+
+// Journal is an append-only log of the listing pages and drugs a run has completed.
+type Journal struct {
+	mu    sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+	pages map[int][]DrugLink
+	drugs map[string]bool
+}
+
+// newRunDir creates a fresh run directory below outDir and writes its manifest. It is named
+// run_<unix time>, with a _2, _3, ... suffix when another run started in the same second, so
+// two runs never share a journal.
+func newRunDir(outDir string, manifest RunManifest) (string, error) {
+	manifest.Created = time.Now().Unix()
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", err
+	}
+	runDir := filepath.Join(outDir, fmt.Sprintf("run_%d", manifest.Created))
+	for n := 2; ; n++ {
+		err := os.Mkdir(runDir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		runDir = filepath.Join(outDir, fmt.Sprintf("run_%d_%d", manifest.Created, n))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return runDir, os.WriteFile(filepath.Join(runDir, MANIFEST_FILE), data, 0644)
+}
+
+func loadRunManifest(runDir string) (RunManifest, error) {
+	var manifest RunManifest
+	data, err := os.ReadFile(filepath.Join(runDir, MANIFEST_FILE))
+	if err != nil {
+		return manifest, fmt.Errorf("loadRunManifest(): %v", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("loadRunManifest(): failed to parse %s: %v", runDir, err)
+	}
+	return manifest, nil
+}
+
+// OpenJournal replays the journal in runDir, if any, and opens it for appending.
+func OpenJournal(runDir string) (*Journal, error) {
+	j := &Journal{
+		pages: make(map[int][]DrugLink),
+		drugs: make(map[string]bool),
+	}
+	path := filepath.Join(runDir, JOURNAL_FILE)
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			var entry JournalEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				// most likely the last line of a run that was killed mid-write
+				log.Printf("🔥 Skipping unreadable journal line %d: %v", line, err)
+				continue
+			}
+			j.replay(entry)
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("OpenJournal(): failed to read %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = file
+	j.enc = json.NewEncoder(file)
+	return j, nil
+}
+
+func (j *Journal) replay(entry JournalEntry) {
+	switch entry.Kind {
+	case "page":
+		j.pages[entry.Page] = entry.Links
+	case "drug":
+		j.drugs[entry.Link] = true
+	}
+}
+
+func (j *Journal) append(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.replay(entry)
+	if err := j.enc.Encode(entry); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// PageDone records a listing page together with the drug links found on it.
+func (j *Journal) PageDone(page int, links []DrugLink) error {
+	return j.append(JournalEntry{Kind: "page", Page: page, Links: links})
+}
+
+// DrugDone records a scraped drug page, call it once the drug has been written to the sinks.
+func (j *Journal) DrugDone(link string, id string) error {
+	return j.append(JournalEntry{Kind: "drug", Link: link, ID: id})
+}
+
+// CompletedPage returns the links of a listing page finished by an earlier session.
+func (j *Journal) CompletedPage(page int) ([]DrugLink, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	links, done := j.pages[page]
+	return links, done
+}
+
+func (j *Journal) CompletedDrug(link string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.drugs[link]
+}
+
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
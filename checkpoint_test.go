@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRunDirDoesNotReuseDirectories(t *testing.T) {
+	outDir := t.TempDir()
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		runDir, err := newRunDir(outDir, RunManifest{Pages: []int{i}})
+		if err != nil {
+			t.Fatalf("newRunDir(): %v", err)
+		}
+		if seen[runDir] {
+			t.Fatalf("newRunDir() returned %s twice", runDir)
+		}
+		seen[runDir] = true
+
+		manifest, err := loadRunManifest(runDir)
+		if err != nil {
+			t.Fatalf("loadRunManifest(%s): %v", filepath.Base(runDir), err)
+		}
+		if len(manifest.Pages) != 1 || manifest.Pages[0] != i {
+			t.Errorf("manifest of %s has pages %v, want [%d]", filepath.Base(runDir), manifest.Pages, i)
+		}
+	}
+}
@@ -0,0 +1,873 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CLI_USAGE = `Usage:
+  go_scrape_drugs scrape pages (--from <n> --to <m> | --all) [--groups <list>] [--regions <list>] [scrape flags]
+  go_scrape_drugs scrape ids [--ids <DB00001,DB00002>] [--file <list|->] [scrape flags] [DB00001 ...]
+  go_scrape_drugs scrape range [scrape flags] <DB00001..DB01000>
+  go_scrape_drugs scrape probe [--ids <DB00001,DB00002>] [--file <list|->] [scrape flags] [DB00001..DB01000 ...]
+  go_scrape_drugs scrape --resume <run-dir> [scrape flags]
+  go_scrape_drugs export --in <results> [--format json|ndjson|csv|sqlite|sdf] [--out <file>]
+  go_scrape_drugs stats --in <results> [--logs <dir>]
+  go_scrape_drugs diff <old results> <new results>
+  go_scrape_drugs migrate [--report <file>] [--ids-out <file>] <results files or dirs...>
+
+Results can be a JSON array file, an NDJSON file or a run directory.
+
+Legacy interactive modes:
+  go_scrape_drugs numPages
+  go_scrape_drugs ID
+
+Run any subcommand with -h to list its flags.
+`
+
+// ScrapeConfig holds the options shared by every scrape subcommand.
+type ScrapeConfig struct {
+	OutDir        string
+	LogDir        string
+	ErrorDelayMin time.Duration
+	ErrorDelay    time.Duration
+	Print         bool
+	Workers       int
+	PerHost       int
+	RPS           float64
+	Burst         int
+	Resume        string
+	Filter        ListingFilter
+	// listing pages already fetched while checking the page range, by page number
+	ListingPages map[int][]DrugLink
+
+	Fsync       bool
+	RotateLines int
+	JSONArray   bool
+	Sinks       string
+	DBPath      string
+
+	InteractionsPageSize int
+	FollowBioLinks       bool
+	FollowCategoryLinks  bool
+	ReferenceMarkers     string
+	Assets               bool
+	Structures           bool
+	AssetsDir            string
+}
+
+func registerScrapeFlags(fs *flag.FlagSet, cfg *ScrapeConfig) {
+	fs.StringVar(&cfg.OutDir, "out", "results", "directory the run directories with results and journal are created in")
+	fs.StringVar(&cfg.Resume, "resume", "", "continue the interrupted run in this run directory")
+	fs.BoolVar(&cfg.Fsync, "fsync", false, "fsync the NDJSON output after every drug")
+	fs.IntVar(&cfg.RotateLines, "rotate-lines", 0, "start a new NDJSON file every n drugs (0 = never)")
+	fs.BoolVar(&cfg.JSONArray, "json-array", false, "also write the run as one JSON array to results.json when it finishes")
+	fs.StringVar(&cfg.Sinks, "sink", "ndjson", "comma separated outputs: ndjson (always on), sqlite")
+	fs.StringVar(&cfg.DBPath, "db", "", "SQLite database for the sqlite sink (default <run-dir>/drugs.db)")
+	fs.IntVar(&cfg.InteractionsPageSize, "interactions-page-size", InteractionsPageSize, "drug interactions fetched per request")
+	fs.BoolVar(&cfg.FollowBioLinks, "follow-bio", false, "fetch the BE and polypeptide pages of targets, enzymes, carriers and transporters for missing gene names and UniProt IDs")
+	fs.BoolVar(&cfg.FollowCategoryLinks, "follow-categories", false, "fetch each drug category page once for its MeSH ID, one extra request per category")
+	fs.BoolVar(&cfg.Assets, "assets", false, "download the structure SVG and thumbnail of every drug")
+	fs.BoolVar(&cfg.Structures, "structures", false, "download the MOL structure file of every small molecule drug, for export --format sdf")
+	fs.StringVar(&cfg.AssetsDir, "assets-dir", "", "content-addressed directory for --assets and --structures, shared between runs (default <out>/assets)")
+	fs.StringVar(&cfg.ReferenceMarkers, "reference-markers", ReferenceMarkers, "what to do with inline [A1234] reference markers: strip, link or keep")
+	fs.StringVar(&cfg.LogDir, "logs", "logs", "directory the run stats are written to")
+	fs.DurationVar(&cfg.ErrorDelayMin, "error-delay-min", DelayBetweenRequests, "lower bound of the random backoff after a failed request")
+	fs.DurationVar(&cfg.ErrorDelay, "error-delay", DelayAfterError, "upper bound of the random backoff after a failed request")
+	fs.Func("delay", "removed, the pause between requests is set with --rps and the backoff with --error-delay-min", func(string) error {
+		return fmt.Errorf("--delay was removed, use --rps for the pause between requests and --error-delay-min for the backoff after a failed request")
+	})
+	fs.BoolVar(&cfg.Print, "print", false, "pretty print the results and stats to stdout")
+	fs.IntVar(&cfg.Workers, "workers", DefaultWorkers, "number of concurrent fetch workers")
+	fs.IntVar(&cfg.PerHost, "per-host", DefaultPerHost, "maximum concurrent requests against a single host")
+	fs.Float64Var(&cfg.RPS, "rps", DefaultRequestsPerSecond, "maximum requests per second across all workers")
+	fs.IntVar(&cfg.Burst, "burst", DefaultBurst, "number of requests allowed to go out back to back")
+}
+
+// apply copies the config into the package level settings used by fetchPage.
+func (cfg *ScrapeConfig) apply() error {
+	if cfg.ErrorDelayMin <= 0 || cfg.ErrorDelay <= cfg.ErrorDelayMin {
+		return fmt.Errorf("--error-delay-min must be positive and smaller than --error-delay")
+	}
+	if cfg.Workers < 1 || cfg.PerHost < 1 {
+		return fmt.Errorf("--workers and --per-host must be at least 1")
+	}
+	if cfg.RPS <= 0 || cfg.Burst < 1 {
+		return fmt.Errorf("--rps must be positive and --burst at least 1")
+	}
+	if cfg.RotateLines < 0 {
+		return fmt.Errorf("--rotate-lines can't be negative")
+	}
+	for _, sink := range strings.Split(cfg.Sinks, ",") {
+		switch strings.TrimSpace(sink) {
+		case "ndjson", "sqlite", "":
+		default:
+			return fmt.Errorf("unknown --sink %q, expected ndjson or sqlite", sink)
+		}
+	}
+	if cfg.InteractionsPageSize < 1 {
+		return fmt.Errorf("--interactions-page-size must be at least 1")
+	}
+	switch cfg.ReferenceMarkers {
+	case MARKERS_STRIP, MARKERS_LINK, MARKERS_KEEP:
+	default:
+		return fmt.Errorf("unknown --reference-markers %q, expected strip, link or keep", cfg.ReferenceMarkers)
+	}
+	DelayBetweenRequests = cfg.ErrorDelayMin
+	DelayAfterError = cfg.ErrorDelay
+	InteractionsPageSize = cfg.InteractionsPageSize
+	FollowBioLinks = cfg.FollowBioLinks
+	FollowCategoryLinks = cfg.FollowCategoryLinks
+	ReferenceMarkers = cfg.ReferenceMarkers
+	DownloadStructureImages = cfg.Assets
+	DownloadStructureFiles = cfg.Structures
+	limiter = NewRateLimiter(cfg.RPS, cfg.Burst)
+	return nil
+}
+
+func runCLI(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(CLI_USAGE)
+		return nil
+	}
+
+	switch args[0] {
+	case "scrape":
+		return runScrapeCommand(args[1:])
+	case "export":
+		return runExportCommand(args[1:])
+	case "stats":
+		return runStatsCommand(args[1:])
+	case "diff":
+		return runDiffCommand(args[1:])
+	case "migrate":
+		return runMigrateCommand(args[1:])
+	case "ID", "numPages":
+		return runLegacyMode(args[0])
+	case "help", "-h", "--help":
+		fmt.Print(CLI_USAGE)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], CLI_USAGE)
+}
+
+func runScrapeCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("scrape needs a mode, one of: pages, ids, range, probe\n%s", CLI_USAGE)
+	}
+
+	// `scrape --resume <run-dir>` takes the pages and links from the run directory
+	mode := args[0]
+	if strings.HasPrefix(mode, "-") {
+		mode = "resume"
+	} else {
+		args = args[1:]
+	}
+
+	cfg := ScrapeConfig{}
+	fs := flag.NewFlagSet("scrape "+mode, flag.ContinueOnError)
+	registerScrapeFlags(fs, &cfg)
+
+	switch mode {
+	case "resume":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if cfg.Resume == "" {
+			return fmt.Errorf("scrape needs a mode, one of: pages, ids, range, probe, or --resume <run-dir>")
+		}
+		if err := cfg.apply(); err != nil {
+			return err
+		}
+		return runScrape(cfg, nil, nil)
+
+	case "pages":
+		from := fs.Int("from", 1, "first listing page to scrape")
+		to := fs.Int("to", 0, "last listing page to scrape, inclusive (default --from)")
+		all := fs.Bool("all", false, "scrape up to the last listing page, as read from the pagination of the first one")
+		groupList := fs.String("groups", "", "only list drugs in any of these comma separated groups: approved, nutraceutical, illicit, investigational, withdrawn, experimental")
+		regionList := fs.String("regions", "", "only list drugs marketed in any of these comma separated regions: us, ca, eu")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		filter, err := ParseListingFilter(*groupList, *regionList)
+		if err != nil {
+			return err
+		}
+		cfg.Filter = filter
+		if err := cfg.apply(); err != nil {
+			return err
+		}
+
+		lastPage, firstPage := lastListingPage(filter, cfg.OutDir)
+		if firstPage != nil {
+			cfg.ListingPages = map[int][]DrugLink{1: firstPage}
+		}
+		if lastPage == 0 {
+			fmt.Printf("ℹ️ No drugs listed for %s, nothing to scrape\n", filter)
+			return nil
+		}
+		if *all {
+			*to = lastPage
+		} else if *to == 0 {
+			*to = *from
+		}
+		if *from < 1 || *to < *from || *to > lastPage {
+			return fmt.Errorf("invalid page range %d..%d (max. %v)", *from, *to, lastPage)
+		}
+
+		pages := make([]int, 0, *to-*from+1)
+		for page := *from; page <= *to; page++ {
+			pages = append(pages, page)
+		}
+		return runScrape(cfg, pages, nil)
+
+	case "ids", "range", "probe":
+		idList := fs.String("ids", "", "comma separated list of DrugBank IDs")
+		idFile := fs.String("file", "", "file listing DrugBank IDs separated by whitespace or commas, - for stdin")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if err := cfg.apply(); err != nil {
+			return err
+		}
+
+		rawIDs := fs.Args()
+		if *idList != "" {
+			rawIDs = append(rawIDs, strings.Split(*idList, ",")...)
+		}
+		isRange := func(arg string) bool { return strings.Contains(arg, "..") }
+		if mode == "range" && !slices.ContainsFunc(rawIDs, isRange) {
+			return fmt.Errorf("scrape range: expected a range such as DB00001..DB01000")
+		}
+		ids, err := parseDrugBankIDArgs(rawIDs)
+		if err != nil {
+			return err
+		}
+		if *idFile != "" {
+			listed, err := readDrugBankIDFile(*idFile)
+			if err != nil {
+				return fmt.Errorf("scrape %s: %s: %v", mode, *idFile, err)
+			}
+			ids = append(ids, listed...)
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("scrape %s: no IDs given", mode)
+		}
+
+		if mode == "probe" {
+			return runProbe(cfg, ids)
+		}
+		links := make([]DrugLink, 0, len(ids))
+		for _, id := range ids {
+			links = append(links, id.Link())
+		}
+		return runScrape(cfg, nil, links)
+	}
+	return fmt.Errorf("unknown scrape mode %q, expected pages, ids, range or probe", mode)
+}
+
+// parseDrugBankIDArgs parses arguments that are each a DrugBank ID or a range of them.
+func parseDrugBankIDArgs(args []string) ([]DrugBankID, error) {
+	var ids []DrugBankID
+	for _, arg := range args {
+		if strings.Contains(arg, "..") {
+			expanded, err := ParseDrugBankIDRange(arg)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, expanded...)
+			continue
+		}
+		id, err := ParseDrugBankID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// runLegacyMode keeps the original prompt driven `ID` and `numPages` modes working.
+func runLegacyMode(mode string) error {
+	cfg := ScrapeConfig{
+		OutDir:        "results",
+		LogDir:        "logs",
+		ErrorDelayMin: DelayBetweenRequests,
+		ErrorDelay:    DelayAfterError,
+		Print:         true,
+		Workers:       DefaultWorkers,
+		PerHost:       DefaultPerHost,
+		RPS:           DefaultRequestsPerSecond,
+		Burst:         DefaultBurst,
+		JSONArray:     true,
+
+		InteractionsPageSize: InteractionsPageSize,
+		FollowCategoryLinks:  FollowCategoryLinks,
+		ReferenceMarkers:     ReferenceMarkers,
+	}
+	if err := cfg.apply(); err != nil {
+		return err
+	}
+	links := make([]DrugLink, 0)
+	pages := make([]int, 0)
+
+	switch mode {
+	case "ID":
+		number := getIntFromUserInput(fmt.Sprintf("Enter DB ID number, i.e. 682 for DB00682 (max. %v)", MAX_DRUG_NUMBER))
+		id, err := DrugBankIDFromNumber(number)
+		if err != nil {
+			return err
+		}
+		links = append(links, id.Link())
+		fmt.Printf("Links: %v", links)
+	case "numPages":
+		lastPage, firstPage := lastListingPage(ListingFilter{}, cfg.OutDir)
+		if firstPage != nil {
+			cfg.ListingPages = map[int][]DrugLink{1: firstPage}
+		}
+		if lastPage == 0 {
+			return fmt.Errorf("the listing shows no drugs")
+		}
+		count := getIntFromUserInput(fmt.Sprintf("Enter number of pages to scrape (max. %v)", lastPage))
+
+		// check if count is within range
+		if count < 1 || count > lastPage {
+			return fmt.Errorf("number of pages must be between 1 and %v, got %v", lastPage, count)
+		}
+
+		for page := 1; page <= count; page++ {
+			pages = append(pages, page)
+		}
+	}
+
+	return runScrape(cfg, pages, links)
+}
+
+// runScrape collects the drug links of the given listing pages, scrapes them together with
+// any explicit links, and saves the results and stats as configured. Every completed page
+// is checkpointed in the run directory, and with cfg.Resume set the pages and links of that
+// run are used instead and only the work missing from its journal is done.
+func runScrape(cfg ScrapeConfig, pages []int, links []DrugLink) error {
+	runDir := cfg.Resume
+	if runDir == "" {
+		var err error
+		runDir, err = newRunDir(cfg.OutDir, RunManifest{Filter: cfg.Filter, Pages: pages, Links: links})
+		if err != nil {
+			return fmt.Errorf("failed to create run directory: %v", err)
+		}
+	} else {
+		manifest, err := loadRunManifest(runDir)
+		if err != nil {
+			return err
+		}
+		cfg.Filter, pages, links = manifest.Filter, manifest.Pages, manifest.Links
+		cfg.ListingPages = nil
+	}
+
+	journal, err := OpenJournal(runDir)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+	fmt.Printf("ℹ️ run directory: %s\n", runDir)
+
+	pool := NewWorkerPool(cfg.Workers, cfg.PerHost, cfg.Workers*2)
+	defer pool.Close()
+
+	if len(pages) > 0 {
+		fmt.Printf("ℹ️ listing pages of %s\n", cfg.Filter)
+		links = append(links, collectPageLinks(pool, journal, cfg.Filter, pages, cfg.ListingPages)...)
+	}
+
+	sinks, err := openSinks(cfg, runDir)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Assets || cfg.Structures {
+		assetsDir := cfg.AssetsDir
+		if assetsDir == "" {
+			assetsDir = filepath.Join(cfg.OutDir, "assets")
+		}
+		if assetStore, err = OpenAssetStore(assetsDir); err != nil {
+			return fmt.Errorf("failed to open assets directory: %v", err)
+		}
+		defer func() {
+			assetStore.Close()
+			assetStore = nil
+		}()
+	}
+
+	for drugInfo := range scrapeDrugLinks(pool, journal, links) {
+		if cfg.Print {
+			PrettyPrint(drugInfo)
+		}
+		for _, sink := range sinks {
+			if err := sink.Write(drugInfo); err != nil {
+				log.Printf("🔥 Error writing %s: %v\n", drugInfo.ID, err)
+			}
+		}
+		if err := journal.DrugDone(drugInfo.Link, drugInfo.ID); err != nil {
+			log.Printf("🔥 Error writing journal: %v\n", err)
+		}
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("🔥 Error closing sink: %v\n", err)
+		}
+	}
+
+	// stats cover the whole run directory, including drugs from resumed sessions
+	paths, err := jsonLinesFiles(runDir)
+	if err != nil {
+		return err
+	}
+	collector := newStatsCollector()
+	if err := readJSONLines(paths, func(drugInfo DrugInfo) error {
+		collector.Add(drugInfo)
+		return nil
+	}); err != nil {
+		return err
+	}
+	drugInfoStats := collector.Finish()
+
+	if cfg.Print {
+		PrettyPrint(drugInfoStats)
+	}
+
+	// save debug data to file and also save the results to a file
+	saveToFile(drugInfoStats, cfg.LogDir, "drugInfoStats.json")
+	if cfg.JSONArray {
+		return writeJSONArrayFile(filepath.Join(runDir, "results.json"), paths)
+	}
+	return nil
+}
+
+// openSinks opens the outputs of a run. The NDJSON files in the run directory are
+// always written, they are what --resume appends to and what stats are computed from.
+func openSinks(cfg ScrapeConfig, runDir string) ([]Sink, error) {
+	jsonLines, err := NewJSONLinesSink(runDir, cfg.Fsync, cfg.RotateLines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open NDJSON output: %v", err)
+	}
+	sinks := []Sink{jsonLines}
+
+	for _, name := range strings.Split(cfg.Sinks, ",") {
+		switch strings.TrimSpace(name) {
+		case "sqlite":
+			dbPath := cfg.DBPath
+			if dbPath == "" {
+				dbPath = filepath.Join(runDir, "drugs.db")
+			}
+			sqlite, err := NewSQLiteSink(dbPath)
+			if err != nil {
+				jsonLines.Close()
+				return nil, fmt.Errorf("failed to open SQLite output: %v", err)
+			}
+			sinks = append(sinks, sqlite)
+		}
+	}
+	return sinks, nil
+}
+
+func writeJSONArrayFile(path string, jsonLinesPaths []string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := writeJSONArray(file, jsonLinesPaths); err != nil {
+		return err
+	}
+	absPath, _ := filepath.Abs(path)
+	fmt.Printf("✅ Data saved to: file://%s\n", absPath)
+	return nil
+}
+
+// loadResults reads a results file, either a JSON array as written by saveToFile, an NDJSON
+// file, or a run directory whose NDJSON files are read in order.
+func loadResults(path string) ([]DrugInfo, error) {
+	var drugInfos []DrugInfo
+	collect := func(drugInfo DrugInfo) error {
+		drugInfos = append(drugInfos, drugInfo)
+		return nil
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		paths, err := jsonLinesFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("loadResults(): no NDJSON results in %s", path)
+		}
+		return drugInfos, readJSONLines(paths, collect)
+	}
+	if strings.HasSuffix(path, ".ndjson") || strings.HasSuffix(path, ".jsonl") {
+		return drugInfos, readJSONLines([]string{path}, collect)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &drugInfos); err != nil {
+		return nil, fmt.Errorf("loadResults(): failed to parse %s: %v", path, err)
+	}
+	return drugInfos, nil
+}
+
+func runExportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	in := fs.String("in", "", "results file or run directory to export")
+	format := fs.String("format", "csv", "output format: json, ndjson, csv, sqlite or sdf")
+	out := fs.String("out", "", "output file (default stdout)")
+	assetsDir := fs.String("assets-dir", filepath.Join("results", "assets"), "assets directory the scrape downloaded the --structures MOL files into, for --format sdf")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("export: --in is required")
+	}
+
+	drugInfos, err := loadResults(*in)
+	if err != nil {
+		return err
+	}
+
+	if *format == "sqlite" {
+		if *out == "" {
+			return fmt.Errorf("export: --format sqlite needs --out <database>")
+		}
+		return exportToSink(drugInfos, func() (Sink, error) { return NewSQLiteSink(*out) })
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "json":
+		jsonData, err := json.MarshalIndent(drugInfos, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(jsonData)
+		return err
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, drugInfo := range drugInfos {
+			if err := enc.Encode(drugInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return writeCSV(w, drugInfos)
+	case "sdf":
+		return writeSDF(w, drugInfos, *assetsDir)
+	}
+	return fmt.Errorf("export: unsupported format %q", *format)
+}
+
+// exportToSink writes drugInfos to the sink returned by open, skipping drugs it rejects.
+func exportToSink(drugInfos []DrugInfo, open func() (Sink, error)) error {
+	sink, err := open()
+	if err != nil {
+		return err
+	}
+	written := 0
+	for _, drugInfo := range drugInfos {
+		if err := sink.Write(drugInfo); err != nil {
+			log.Printf("🔥 Skipping drug: %v\n", err)
+			continue
+		}
+		written++
+	}
+	fmt.Printf("✅ Exported %d of %d drugs\n", written, len(drugInfos))
+	return sink.Close()
+}
+
+func writeCSV(w io.Writer, drugInfos []DrugInfo) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "molecule", "cas", "type", "formula", "smiles", "inchi_key", "groups", "is_stub", "link", "products", "brands", "packagers", "dosage_forms", "atc_codes", "pubchem_cid", "chebi", "chembl", "kegg_drug", "unii", "logp", "water_solubility", "polar_surface_area", "super_class", "class", "direct_parent", "food_interactions", "half_life_hours", "protein_binding_percent", "pubmed_ids", "clinical_trials", "max_trial_phase", "structure_svg"})
+	for _, d := range drugInfos {
+		cw.Write([]string{
+			d.ID,
+			d.Molecule,
+			d.CAS,
+			d.Type,
+			d.Formula,
+			d.Smiles,
+			d.InChIKey,
+			strings.Join(d.Groups, ";"),
+			strconv.FormatBool(d.IsStub),
+			d.Link,
+			strconv.Itoa(len(d.Products)),
+			strings.Join(brandNames(d), ";"),
+			strings.Join(d.Packagers, ";"),
+			strings.Join(dosageFormNames(d), ";"),
+			strings.Join(atcCodeList(d), ";"),
+			strings.Join(crossReferenceIDs(d, SourcePubChemCID), ";"),
+			strings.Join(crossReferenceIDs(d, SourceChEBI), ";"),
+			strings.Join(crossReferenceIDs(d, SourceChEMBL), ";"),
+			strings.Join(crossReferenceIDs(d, SourceKEGGDrug), ";"),
+			strings.Join(crossReferenceIDs(d, SourceUNII), ";"),
+			csvPropertyValue(d, "logp"),
+			csvPropertyValue(d, "water_solubility"),
+			csvPropertyValue(d, "polar_surface_area"),
+			csvTaxonomy(d, func(t *ChemicalTaxonomy) string { return t.SuperClass }),
+			csvTaxonomy(d, func(t *ChemicalTaxonomy) string { return t.Class }),
+			csvTaxonomy(d, func(t *ChemicalTaxonomy) string { return t.DirectParent }),
+			strings.Join(foodInteractionCategoryList(d), ";"),
+			csvPKValue(d, "half_life"),
+			csvPKValue(d, "protein_binding"),
+			strings.Join(pubMedIDs(d), ";"),
+			csvClinicalTrials(d),
+			d.ClinicalTrials.MaxPhase(),
+			assetPath(d, "structure_svg"),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvPropertyValue formats a physchem property for writeCSV, empty when the drug doesn't have it.
+func csvPropertyValue(d DrugInfo, name string) string {
+	if value, ok := propertyValue(d, name); ok {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return ""
+}
+
+// csvTaxonomy reads one level of the ClassyFire taxonomy for writeCSV.
+func csvTaxonomy(d DrugInfo, level func(*ChemicalTaxonomy) string) string {
+	if d.Taxonomy == nil {
+		return ""
+	}
+	return level(d.Taxonomy)
+}
+
+// csvPKValue formats the normalized range of a pharmacokinetic parameter for writeCSV, "4-6" or "4".
+func csvPKValue(d DrugInfo, parameter string) string {
+	if d.Pharmacokinetics == nil {
+		return ""
+	}
+	value := d.Pharmacokinetics.pkParameters()[parameter]
+	switch {
+	case value == nil || (value.NormalizedMin == nil && value.NormalizedMax == nil):
+		return ""
+	case value.NormalizedMin == nil:
+		return "<" + strconv.FormatFloat(*value.NormalizedMax, 'g', 4, 64)
+	case value.NormalizedMax == nil:
+		return ">" + strconv.FormatFloat(*value.NormalizedMin, 'g', 4, 64)
+	}
+	low := strconv.FormatFloat(*value.NormalizedMin, 'g', 4, 64)
+	if *value.NormalizedMax == *value.NormalizedMin {
+		return low
+	}
+	return low + "-" + strconv.FormatFloat(*value.NormalizedMax, 'g', 4, 64)
+}
+
+// csvClinicalTrials counts the drug's clinical trials for writeCSV, empty when they weren't scraped.
+func csvClinicalTrials(d DrugInfo) string {
+	if d.ClinicalTrials == nil {
+		return ""
+	}
+	return strconv.Itoa(d.ClinicalTrials.Trials)
+}
+
+func runStatsCommand(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	in := fs.String("in", "", "results file or run directory to compute stats for")
+	logDir := fs.String("logs", "", "also save the stats to this directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("stats: --in is required")
+	}
+
+	drugInfos, err := loadResults(*in)
+	if err != nil {
+		return err
+	}
+
+	drugInfoStats := computeDrugInfoStats(drugInfos)
+	PrettyPrint(drugInfoStats)
+	if *logDir != "" {
+		saveToFile(drugInfoStats, *logDir, "drugInfoStats.json")
+	}
+	return nil
+}
+
+func runDiffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("diff: expected two results files")
+	}
+
+	before, err := loadResults(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadResults(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	added, removed, changed := diffResults(before, after)
+	for _, id := range added {
+		fmt.Printf("+ %s\n", id)
+	}
+	for _, id := range removed {
+		fmt.Printf("- %s\n", id)
+	}
+	for _, id := range sortedKeys(changed) {
+		fmt.Printf("~ %s: %s\n", id, strings.Join(changed[id], ", "))
+	}
+	fmt.Printf("ℹ️ %d added, %d removed, %d changed\n", len(added), len(removed), len(changed))
+	return nil
+}
+
+// diffResults compares two result sets by drug ID and reports which fields changed per drug.
+func diffResults(before, after []DrugInfo) (added, removed []string, changed map[string][]string) {
+	beforeByID := make(map[string]DrugInfo, len(before))
+	for _, d := range before {
+		beforeByID[d.ID] = d
+	}
+	afterByID := make(map[string]DrugInfo, len(after))
+	for _, d := range after {
+		afterByID[d.ID] = d
+	}
+
+	changed = make(map[string][]string)
+	for _, id := range sortedKeys(afterByID) {
+		old, exists := beforeByID[id]
+		if !exists {
+			added = append(added, id)
+			continue
+		}
+
+		oldVal := reflect.ValueOf(old)
+		newVal := reflect.ValueOf(afterByID[id])
+		for i := 0; i < oldVal.NumField(); i++ {
+			if !reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+				changed[id] = append(changed[id], oldVal.Type().Field(i).Name)
+			}
+		}
+	}
+	for _, id := range sortedKeys(beforeByID) {
+		if _, exists := afterByID[id]; !exists {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed, changed
+}
+
+// migrateSkippedFiles are the files next to results that hold no drugs: the journal and
+// manifest of a run directory and the listing cache of the output directory.
+var migrateSkippedFiles = []string{JOURNAL_FILE, MANIFEST_FILE, LISTING_CACHE_FILE}
+
+// runMigrateCommand flags historical results files whose chemical identifiers were
+// lowercased by older versions, and lists the drugs that need to be scraped again.
+func runMigrateCommand(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	reportPath := fs.String("report", "", "write the full report as JSON to this file")
+	idsOut := fs.String("ids-out", "", "write the affected DrugBank IDs, one per line, to this file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	targets := fs.Args()
+	if len(targets) == 0 {
+		targets = []string{"results"}
+	}
+
+	var paths []string
+	for _, target := range targets {
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			for _, pattern := range []string{"*.json", "*.ndjson", "*/drugs-*.ndjson"} {
+				matches, _ := filepath.Glob(filepath.Join(target, pattern))
+				for _, match := range matches {
+					if !slices.Contains(migrateSkippedFiles, filepath.Base(match)) {
+						paths = append(paths, match)
+					}
+				}
+			}
+			continue
+		}
+		paths = append(paths, target)
+	}
+	sort.Strings(paths)
+	// a run directory is globbed both on its own and through its parent
+	paths = slices.Compact(paths)
+
+	var reports []CasingReport
+	affected := NewUniqueSet[string, bool]()
+	for _, path := range paths {
+		report := auditCasing(path)
+		reports = append(reports, report)
+
+		switch {
+		case report.Error != "":
+			fmt.Printf("⚠️ %s: %s\n", path, report.Error)
+		case len(report.AffectedIDs) > 0:
+			fmt.Printf("🔥 %s: %d of %d drugs have lowercased identifiers %v\n", path, len(report.AffectedIDs), report.Drugs, report.AffectedField)
+		}
+		for _, id := range report.AffectedIDs {
+			affected.Add(id, true)
+		}
+	}
+	fmt.Printf("ℹ️ checked %d files, %d distinct drugs need to be scraped again\n", len(paths), len(affected))
+
+	if *reportPath != "" {
+		jsonData, err := json.MarshalIndent(reports, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, jsonData, 0644); err != nil {
+			return err
+		}
+	}
+	if *idsOut != "" {
+		ids := sortedKeys(affected)
+		if err := os.WriteFile(*idsOut, []byte(strings.Join(ids, "\n")+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
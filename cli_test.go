@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunScrapeCommandArguments(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"range"}, "expected a range"},
+		{[]string{"range", "DB00001", "DB00002"}, "expected a range"},
+		{[]string{"range", "DB00000..DB00002"}, "out of range"},
+		{[]string{"ids"}, "no IDs given"},
+		{[]string{"ids", "--ids", "DB00001,aspirin"}, "invalid DrugBank ID"},
+		{[]string{"ids", "--delay", "5s", "DB00001"}, "--delay was removed"},
+		{[]string{"ids", "--error-delay-min", "30s", "--error-delay", "20s", "DB00001"}, "--error-delay-min must be positive"},
+		{[]string{"pages", "--from", "1", "--rps", "0"}, "--rps must be positive"},
+		{[]string{"crawl"}, "unknown scrape mode"},
+	}
+	for _, tt := range tests {
+		err := runScrapeCommand(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runScrapeCommand(%q) error = %v, want one containing %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const CLINICAL_TRIALS_URL = "https://go.drugbank.com/drugs/%s/clinical_trials/aggregate.json?start=%d&length=%d&_=%d"
+
+// rows per clinical_trials/aggregate.json request
+var ClinicalTrialsPageSize = 100
+
+// ClinicalTrialGroup is one cell of the clinical trials matrix: how many trials of a phase and
+// status study the drug for a condition.
+type ClinicalTrialGroup struct {
+	Phases      []string `json:"phases,omitempty"` // "0" to "4", several for combined phases such as "1, 2" or "2/3"
+	Status      string   `json:"status,omitempty"`
+	Purpose     string   `json:"purpose,omitempty"`
+	Condition   string   `json:"condition,omitempty"`
+	ConditionID string   `json:"condition_id,omitempty"` // DrugBank condition ID, e.g. DBCOND0038404
+	Count       int      `json:"count"`
+	Link        string   `json:"link,omitempty"` // list of the trials on DrugBank
+}
+
+// ClinicalTrials is the clinical trials matrix of a drug, Complete is false when not every
+// row of the table could be fetched.
+type ClinicalTrials struct {
+	Groups   []ClinicalTrialGroup `json:"groups"`
+	Trials   int                  `json:"trials"` // sum of the group counts
+	Complete bool                 `json:"complete"`
+}
+
+var (
+	clinicalTrialLinkRegexp  = regexp.MustCompile(`<a [^>]*href="([^"]*)"[^>]*>([^<]*)</a>`)
+	clinicalTrialTagRegexp   = regexp.MustCompile(`<[^>]+>`)
+	clinicalTrialPhaseRegexp = regexp.MustCompile(`[0-4]`)
+)
+
+// cellText strips the markup of a table cell.
+func cellText(cell string) string {
+	return normalizeFieldValue("", html.UnescapeString(clinicalTrialTagRegexp.ReplaceAllString(cell, " ")))
+}
+
+// ParseClinicalTrials parses one page of the clinical trials aggregate table, rows are
+// [phase, status, purpose, conditions, count]. Also returns the recordsTotal of the whole table.
+func ParseClinicalTrials(jsonData string) ([]ClinicalTrialGroup, []string, int, error) {
+	var table DataTablesPage
+	if err := json.Unmarshal([]byte(jsonData), &table); err != nil {
+		return nil, nil, 0, err
+	}
+
+	var groups []ClinicalTrialGroup
+	var warnings []string
+	for _, row := range table.Data {
+		if len(row) < 5 {
+			warnings = append(warnings, fmt.Sprintf("clinical trials row has %d columns, expected 5: %q", len(row), row))
+			continue
+		}
+
+		group := ClinicalTrialGroup{
+			Phases:    clinicalTrialPhaseRegexp.FindAllString(cellText(row[0]), -1),
+			Status:    cellText(row[1]),
+			Purpose:   cellText(row[2]),
+			Condition: cellText(row[3]),
+		}
+		if m := clinicalTrialLinkRegexp.FindStringSubmatch(row[3]); m != nil {
+			group.ConditionID = m[1][strings.LastIndex(m[1], "/")+1:]
+		}
+
+		count, err := strconv.Atoi(strings.ReplaceAll(cellText(row[4]), ",", ""))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("no trial count in clinical trials row: %q", row[4]))
+			continue
+		}
+		group.Count = count
+		if m := clinicalTrialLinkRegexp.FindStringSubmatch(row[4]); m != nil {
+			group.Link = html.UnescapeString(m[1])
+			if strings.HasPrefix(group.Link, "/") {
+				group.Link = "https://go.drugbank.com" + group.Link
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, warnings, table.RecordsTotal, nil
+}
+
+// handleClinicalTrials pages through the clinical trials matrix the Clinical Trials section loads.
+func handleClinicalTrials(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleClinicalTrials(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleClinicalTrials(): invalid arguments")
+	}
+
+	trials := &ClinicalTrials{}
+	json.ClinicalTrials = trials
+
+	id := json.ID
+	read, total, err := pageDataTable(func(start int) string {
+		return fmt.Sprintf(CLINICAL_TRIALS_URL, id, start, ClinicalTrialsPageSize, time.Now().Unix())
+	}, func(page string) (int, int, error) {
+		groups, warnings, recordsTotal, err := ParseClinicalTrials(page)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, warning := range warnings {
+			log.Printf("🔥 %s: %s\n", id, warning)
+		}
+		for _, group := range groups {
+			trials.Groups = append(trials.Groups, group)
+			trials.Trials += group.Count
+		}
+		return len(groups) + len(warnings), recordsTotal, nil
+	})
+	if err != nil {
+		return fmt.Errorf("handleClinicalTrials(): %v", err)
+	}
+
+	trials.Complete = read >= total
+	return nil
+}
+
+// MaxPhase is the highest phase any trial of the drug reached, "" without trials.
+func (trials *ClinicalTrials) MaxPhase() string {
+	highest := ""
+	if trials == nil {
+		return highest
+	}
+	for _, group := range trials.Groups {
+		for _, phase := range group.Phases {
+			if phase > highest {
+				highest = phase
+			}
+		}
+	}
+	return highest
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseClinicalTrials(t *testing.T) {
+	page := `{"recordsTotal": 4, "data": [
+		["<span>4</span>", "Completed", "Treatment", "<a href=\"/indications/DBCOND0038404\">Hypertension</a>", "<a href=\"/drugs/DB00001/clinical_trials?conditions=DBCOND0038404&amp;phase=4\">1,234</a>"],
+		["1, 2", "Recruiting", "Prevention", "Atrial Fibrillation", "3"],
+		["2/3", "Terminated", "Treatment", "Stroke", "not a count"],
+		["Not Available", "Unknown Status"]
+	]}`
+
+	groups, warnings, total, err := ParseClinicalTrials(page)
+	if err != nil {
+		t.Fatalf("ParseClinicalTrials(): %v", err)
+	}
+	if total != 4 || len(warnings) != 2 || len(groups) != 2 {
+		t.Fatalf("got %d groups, %d warnings, total %d, want 2, 2, 4: %q", len(groups), len(warnings), total, warnings)
+	}
+
+	first := groups[0]
+	if !slices.Equal(first.Phases, []string{"4"}) || first.Status != "Completed" || first.Condition != "Hypertension" || first.ConditionID != "DBCOND0038404" || first.Count != 1234 {
+		t.Errorf("first group = %+v", first)
+	}
+	if first.Link != "https://go.drugbank.com/drugs/DB00001/clinical_trials?conditions=DBCOND0038404&phase=4" {
+		t.Errorf("first group link = %q", first.Link)
+	}
+	if !slices.Equal(groups[1].Phases, []string{"1", "2"}) {
+		t.Errorf("phases of %q = %q, want [1 2]", "1, 2", groups[1].Phases)
+	}
+}
+
+func TestClinicalTrialPhases(t *testing.T) {
+	tests := []struct {
+		cell string
+		want []string
+	}{
+		{"4", []string{"4"}},
+		{"1, 2", []string{"1", "2"}},
+		{"2/3", []string{"2", "3"}},
+		{"Not Available", nil},
+	}
+	for _, tt := range tests {
+		if got := clinicalTrialPhaseRegexp.FindAllString(cellText(tt.cell), -1); !slices.Equal(got, tt.want) {
+			t.Errorf("phases of %q = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestClinicalTrialsMaxPhase(t *testing.T) {
+	var none *ClinicalTrials
+	if got := none.MaxPhase(); got != "" {
+		t.Errorf("MaxPhase() of nil = %q", got)
+	}
+	trials := &ClinicalTrials{Groups: []ClinicalTrialGroup{{Phases: []string{"1"}}, {Phases: []string{"2", "3"}}, {}}}
+	if got := trials.MaxPhase(); got != "3" {
+		t.Errorf("MaxPhase() = %q, want 3", got)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Source names an external database a drug is cross-referenced in.
+type Source string
+
+const (
+	SourcePubChemCID    Source = "pubchem_cid"
+	SourcePubChemSID    Source = "pubchem_sid"
+	SourceChEBI         Source = "chebi"
+	SourceChEMBL        Source = "chembl"
+	SourceChemSpider    Source = "chemspider"
+	SourceKEGGDrug      Source = "kegg_drug"
+	SourceKEGGCompound  Source = "kegg_compound"
+	SourceRxNorm        Source = "rxnorm"
+	SourceUNII          Source = "unii"
+	SourceZINC          Source = "zinc"
+	SourcePDB           Source = "pdb"
+	SourceBindingDB     Source = "bindingdb"
+	SourcePharmGKB      Source = "pharmgkb"
+	SourceIUPHAR        Source = "iuphar"
+	SourceTTD           Source = "ttd"
+	SourceHMDB          Source = "hmdb"
+	SourceWikipedia     Source = "wikipedia"
+	SourceDrugsCom      Source = "drugs_com"
+	SourceRxList        Source = "rxlist"
+	SourceFDALabel      Source = "fda_label"
+	SourceMSDS          Source = "msds"
+	SourcePDRHealth     Source = "pdrhealth"
+	SourceDailyMed      Source = "dailymed"
+	SourceGenBank       Source = "genbank"
+	SourceUniProt       Source = "uniprot"
+	SourceMedlinePlus   Source = "medlineplus"
+	SourceNDC           Source = "ndc"
+	SourceFoodCompounds Source = "foodb"
+)
+
+// ExternalID is one identifier of a drug in another database, with the link DrugBank gives for it.
+type ExternalID struct {
+	ID   string `json:"id"`
+	Link string `json:"link,omitempty"`
+}
+
+// CrossReferences maps external databases to the drug's identifiers in them.
+type CrossReferences map[Source][]ExternalID
+
+// crossReferenceSources maps the normalized titles of the External Identifiers and External
+// Links sections to their Source, anything missing gets a source slugged from its title.
+var crossReferenceSources = map[string]Source{
+	"pubchem compound":             SourcePubChemCID,
+	"pubchem cid":                  SourcePubChemCID,
+	"pubchem substance":            SourcePubChemSID,
+	"pubchem sid":                  SourcePubChemSID,
+	"chebi":                        SourceChEBI,
+	"chembl":                       SourceChEMBL,
+	"chemspider":                   SourceChemSpider,
+	"kegg drug":                    SourceKEGGDrug,
+	"kegg compound":                SourceKEGGCompound,
+	"rxnav":                        SourceRxNorm,
+	"rxnorm":                       SourceRxNorm,
+	"unii":                         SourceUNII,
+	"zinc":                         SourceZINC,
+	"pdbe ligand":                  SourcePDB,
+	"pdb":                          SourcePDB,
+	"pdb entries":                  SourcePDB,
+	"bindingdb":                    SourceBindingDB,
+	"pharmgkb":                     SourcePharmGKB,
+	"iuphar":                       SourceIUPHAR,
+	"guide to pharmacology":        SourceIUPHAR,
+	"therapeutic targets database": SourceTTD,
+	"ttd":                          SourceTTD,
+	"human metabolome database":    SourceHMDB,
+	"hmdb":                         SourceHMDB,
+	"wikipedia":                    SourceWikipedia,
+	"drugs.com":                    SourceDrugsCom,
+	"rxlist":                       SourceRxList,
+	"fda label":                    SourceFDALabel,
+	"msds":                         SourceMSDS,
+	"pdrhealth":                    SourcePDRHealth,
+	"dailymed":                     SourceDailyMed,
+	"genbank":                      SourceGenBank,
+	"uniprot":                      SourceUniProt,
+	"medlineplus":                  SourceMedlinePlus,
+	"national drug code directory": SourceNDC,
+	"foodb":                        SourceFoodCompounds,
+}
+
+var sourceSlugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sourceOf returns the Source of a section title such as "PubChem Compound".
+func sourceOf(title string) Source {
+	title = normalize(title)
+	if source, known := crossReferenceSources[title]; known {
+		return source
+	}
+	return Source(strings.Trim(sourceSlugRegexp.ReplaceAllString(title, "_"), "_"))
+}
+
+// Add records an identifier unless it is already listed for that source.
+func (refs CrossReferences) Add(source Source, id ExternalID) {
+	if id.ID == "" && id.Link == "" {
+		return
+	}
+	for _, existing := range refs[source] {
+		if existing == id {
+			return
+		}
+	}
+	refs[source] = append(refs[source], id)
+}
+
+// externalIDs reads the identifiers of one dd, links are kept with their text as the ID.
+func externalIDs(dd *goquery.Selection) []ExternalID {
+	var ids []ExternalID
+	dd.Find("a").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		if strings.HasPrefix(href, "/") {
+			href = "https://go.drugbank.com" + href
+		}
+		ids = append(ids, ExternalID{ID: normalizeFieldValue("", a.Text()), Link: href})
+	})
+	if len(ids) == 0 {
+		// identifiers without a link, possibly several separated by commas
+		for _, id := range strings.Split(dd.Text(), ",") {
+			if id = normalizeFieldValue("", id); id != "" && !strings.EqualFold(id, "not available") {
+				ids = append(ids, ExternalID{ID: id})
+			}
+		}
+	}
+	return ids
+}
+
+// handleCrossReferences reads the nested dl of the External Identifiers and External Links
+// sections, and the UNII listed with the drug's identification.
+func handleCrossReferences(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleCrossReferences(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleCrossReferences(): invalid arguments")
+	}
+
+	if json.CrossReferences == nil {
+		json.CrossReferences = make(CrossReferences)
+	}
+
+	if name == "Unii" {
+		for _, id := range externalIDs(sibling) {
+			json.CrossReferences.Add(SourceUNII, id)
+		}
+		return nil
+	}
+
+	sibling.Find("dl").Each(func(_ int, dl *goquery.Selection) {
+		dl.ChildrenFiltered("dt").Each(func(_ int, dt *goquery.Selection) {
+			source := sourceOf(dt.Text())
+			for _, id := range externalIDs(dt.NextFiltered("dd")) {
+				json.CrossReferences.Add(source, id)
+			}
+		})
+	})
+	return nil
+}
+
+// crossReferenceIDs lists the drug's identifiers in one source.
+func crossReferenceIDs(drugInfo DrugInfo, source Source) []string {
+	var ids []string
+	for _, id := range drugInfo.CrossReferences[source] {
+		ids = append(ids, id.ID)
+	}
+	return ids
+}
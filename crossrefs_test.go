@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHandleCrossReferences(t *testing.T) {
+	page := fixture(t, `<dl>
+		<dt id="unii">UNII</dt><dd id="unii-value">R16CO5Y76E</dd>
+		<dt id="external-identifiers">External IDs</dt><dd id="external-identifiers-value"><dl>
+			<dt>PubChem Compound</dt><dd><a href="https://pubchem.ncbi.nlm.nih.gov/compound/2244">2244</a></dd>
+			<dt>ChEBI</dt><dd><a href="https://www.ebi.ac.uk/chebi/searchId.do?chebiId=15365">15365</a></dd>
+			<dt>PDBe Ligand</dt><dd><a href="https://www.ebi.ac.uk/pdbe-srv/pdbechem/chemicalCompound/show/AIN">AIN</a></dd>
+			<dt>PDB Entries</dt><dd><a href="https://www.rcsb.org/structure/1oxr">1oxr</a> / <a href="https://www.rcsb.org/structure/1tgm">1tgm</a></dd>
+			<dt>Guide to Pharmacology</dt><dd>4139, 4139</dd>
+			<dt>Some New Database</dt><dd>SND-1</dd>
+			<dt>RxNav</dt><dd>Not Available</dd>
+		</dl></dd>
+		<dt id="external-links">External Links</dt><dd id="external-links-value"><dl>
+			<dt>Wikipedia</dt><dd><a href="/redirect?url=wiki">Aspirin</a></dd>
+		</dl></dd>
+	</dl>`)
+
+	var drugInfo DrugInfo
+	for _, field := range []struct{ id, name string }{{"unii", "Unii"}, {"external-identifiers", "ExternalIdentifiers"}, {"external-links", "ExternalLinks"}} {
+		if err := handleCrossReferences(page.Find("#"+field.id+"-value"), &drugInfo, field.name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		source Source
+		want   []string
+	}{
+		{SourceUNII, []string{"R16CO5Y76E"}},
+		{SourcePubChemCID, []string{"2244"}},
+		{SourceChEBI, []string{"15365"}},
+		{SourcePDB, []string{"AIN", "1oxr", "1tgm"}},
+		{SourceIUPHAR, []string{"4139"}},
+		{"some_new_database", []string{"SND-1"}},
+		{SourceRxNorm, nil},
+		{SourceWikipedia, []string{"Aspirin"}},
+	}
+	for _, tt := range tests {
+		if got := crossReferenceIDs(drugInfo, tt.source); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+	if link := drugInfo.CrossReferences[SourceWikipedia][0].Link; link != "https://go.drugbank.com/redirect?url=wiki" {
+		t.Errorf("relative link resolved to %q", link)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+// DataTablesPage is one page of the JSON served by DrugBank's DataTables endpoints, such as
+// the drug interactions and the clinical trials of a drug.
+type DataTablesPage struct {
+	Draw            int
+	RecordsTotal    int `json:"recordsTotal"`
+	RecordsFiltered int `json:"recordsFiltered"`
+	Data            [][]string
+}
+
+// pageDataTable fetches the pages of a DataTables endpoint until recordsTotal rows were read,
+// recordsTotal is only known after the first page. link builds the URL of the page starting
+// at a row, parse handles one page and returns how many rows it read, parsed or not, and the
+// recordsTotal of the table. The table is complete when read >= total.
+func pageDataTable(link func(start int) string, parse func(page string) (rows int, total int, err error)) (read int, total int, err error) {
+	total = -1
+	for total < 0 || read < total {
+		page, _, err := fetchPage(link(read), false)
+		if err != nil {
+			return read, total, fmt.Errorf("failed to fetch page at %d of %d: %v", read, total, err)
+		}
+
+		rows, recordsTotal, err := parse(page)
+		if err != nil {
+			return read, total, fmt.Errorf("failed to parse JSON: %v", err)
+		}
+		total = recordsTotal
+
+		// an empty page would never get us to recordsTotal
+		if rows == 0 {
+			break
+		}
+		read += rows
+	}
+	return read, total, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestPageDataTable(t *testing.T) {
+	// 5 rows served 2 at a time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		rows := min(2, 5-start)
+		fmt.Fprintf(w, `{"recordsTotal": 5, "data": %d}`, rows)
+	}))
+	defer server.Close()
+
+	var starts []int
+	read, total, err := pageDataTable(func(start int) string {
+		starts = append(starts, start)
+		return fmt.Sprintf("%s/table.json?start=%d", server.URL, start)
+	}, func(page string) (int, int, error) {
+		var table struct {
+			RecordsTotal int `json:"recordsTotal"`
+			Data         int `json:"data"`
+		}
+		_, err := fmt.Sscanf(page, `{"recordsTotal": %d, "data": %d}`, &table.RecordsTotal, &table.Data)
+		return table.Data, table.RecordsTotal, err
+	})
+	if err != nil {
+		t.Fatalf("pageDataTable(): %v", err)
+	}
+	if read != 5 || total != 5 {
+		t.Errorf("read %d of %d rows, want 5 of 5", read, total)
+	}
+	if fmt.Sprint(starts) != "[0 2 4]" {
+		t.Errorf("requested pages starting at %v, want [0 2 4]", starts)
+	}
+}
+
+func TestPageDataTableStopsOnEmptyPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "page")
+	}))
+	defer server.Close()
+
+	requests := 0
+	read, total, err := pageDataTable(func(start int) string {
+		requests++
+		return server.URL
+	}, func(page string) (int, int, error) {
+		return 0, 10, nil
+	})
+	if err != nil || requests != 1 || read != 0 || total != 10 {
+		t.Errorf("pageDataTable() = %d, %d, %v after %d requests, want 0, 10, nil after 1", read, total, err, requests)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DrugBankID is a validated DrugBank accession number, "DB" followed by five digits.
+type DrugBankID string
+
+const MAX_DRUG_NUMBER = 99999
+
+var (
+	drugBankIDRegexp = regexp.MustCompile(`^DB[0-9]{5}$`)
+	// IDs in a list file are separated by whitespace or commas
+	drugBankIDSeparatorRegexp = regexp.MustCompile(`[\s,]+`)
+)
+
+// ParseDrugBankID accepts a DrugBank ID in any case, or a bare number of up to five digits
+// which is zero padded, i.e. "682" is DB00682.
+func ParseDrugBankID(raw string) (DrugBankID, error) {
+	id := strings.ToUpper(strings.TrimSpace(raw))
+	if drugBankIDRegexp.MatchString(id) {
+		n, _ := strconv.Atoi(id[2:])
+		return DrugBankIDFromNumber(n)
+	}
+	if n, err := strconv.Atoi(id); err == nil && len(id) <= 5 {
+		return DrugBankIDFromNumber(n)
+	}
+	return "", fmt.Errorf("invalid DrugBank ID %q, expected DB followed by five digits", raw)
+}
+
+// DrugBankIDFromNumber returns the ID of the n-th drug, 1 is DB00001.
+func DrugBankIDFromNumber(n int) (DrugBankID, error) {
+	if n < 1 || n > MAX_DRUG_NUMBER {
+		return "", fmt.Errorf("DrugBank ID number %d out of range 1..%d", n, MAX_DRUG_NUMBER)
+	}
+	return DrugBankID(fmt.Sprintf("DB%05d", n)), nil
+}
+
+// Number is the numeric part of the ID.
+func (id DrugBankID) Number() int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(string(id), "DB"))
+	return n
+}
+
+// Link is the drug page of the ID.
+func (id DrugBankID) Link() DrugLink {
+	return DrugLink{Name: string(id), Link: fmt.Sprintf(DRUG_BASE_URL, id)}
+}
+
+// ParseDrugBankIDRange expands "DB00001..DB01000" into every ID between both ends, inclusive.
+func ParseDrugBankIDRange(raw string) ([]DrugBankID, error) {
+	from, to, found := strings.Cut(raw, "..")
+	if !found {
+		return nil, fmt.Errorf("invalid DrugBank ID range %q, expected <from>..<to>", raw)
+	}
+	first, err := ParseDrugBankID(from)
+	if err != nil {
+		return nil, err
+	}
+	last, err := ParseDrugBankID(to)
+	if err != nil {
+		return nil, err
+	}
+	if last.Number() < first.Number() {
+		return nil, fmt.Errorf("invalid DrugBank ID range %q, %s comes after %s", raw, first, last)
+	}
+
+	ids := make([]DrugBankID, 0, last.Number()-first.Number()+1)
+	for n := first.Number(); n <= last.Number(); n++ {
+		id, err := DrugBankIDFromNumber(n)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ReadDrugBankIDs reads the IDs listed in r, separated by whitespace or commas. Everything
+// after a # on a line is a comment.
+func ReadDrugBankIDs(r io.Reader) ([]DrugBankID, error) {
+	var ids []DrugBankID
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, raw := range drugBankIDSeparatorRegexp.Split(strings.TrimSpace(text), -1) {
+			if raw == "" {
+				continue
+			}
+			id, err := ParseDrugBankID(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, scanner.Err()
+}
+
+// readDrugBankIDFile reads the IDs of a list file, "-" reads them from stdin.
+func readDrugBankIDFile(path string) ([]DrugBankID, error) {
+	if path == "-" {
+		return ReadDrugBankIDs(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadDrugBankIDs(file)
+}
+
+// ProbeResult is one line of the file written by runProbe.
+type ProbeResult struct {
+	ID    DrugBankID `json:"id"`
+	Found bool       `json:"found"`
+	Error string     `json:"error,omitempty"` // the page could be neither fetched nor found missing
+}
+
+// runProbe requests the drug page of every ID without scraping it and writes which exist to
+// <out>/probe_<unix time>.ndjson, in completion order.
+func runProbe(cfg ScrapeConfig, ids []DrugBankID) error {
+	if err := os.MkdirAll(cfg.OutDir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.OutDir, fmt.Sprintf("probe_%d.ndjson", time.Now().Unix()))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	enc := json.NewEncoder(file)
+
+	pool := NewWorkerPool(cfg.Workers, cfg.PerHost, cfg.Workers*2)
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	results := make(chan ProbeResult)
+	go func() {
+		for _, id := range ids {
+			wg.Add(1)
+			id := id
+			link := id.Link().Link
+			pool.Submit(link, func() {
+				defer wg.Done()
+				_, _, err := fetchPage(link, false)
+				result := ProbeResult{ID: id, Found: err == nil}
+				if err != nil && !errors.Is(err, errPageNotFound) {
+					result.Error = err.Error()
+				}
+				results <- result
+			})
+		}
+		wg.Wait()
+		close(results)
+	}()
+
+	// results are drained to the end even after a failed write, the workers block on them otherwise
+	var writeErr error
+	found, missing, failed := 0, 0, 0
+	for result := range results {
+		switch {
+		case result.Found:
+			found++
+		case result.Error != "":
+			failed++
+		default:
+			missing++
+		}
+		if writeErr == nil {
+			writeErr = enc.Encode(result)
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	fmt.Printf("✅ Probed %d IDs: %d found, %d not found, %d failed, written to %s\n", len(ids), found, missing, failed, path)
+	return nil
+}
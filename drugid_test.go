@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDrugBankID(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    DrugBankID
+		wantErr bool
+	}{
+		{"DB00682", "DB00682", false},
+		{" db00001 ", "DB00001", false},
+		{"682", "DB00682", false},
+		{"00682", "DB00682", false},
+		{"99999", "DB99999", false},
+		{"0", "", true},
+		{"DB00000", "", true},
+		{"00000", "", true},
+		{"-1", "", true},
+		{"100000", "", true},
+		{"DB0682", "", true},
+		{"DB000682", "", true},
+		{"BE0000048", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDrugBankID(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseDrugBankID(%q) = %q, %v, want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDrugBankIDFromNumber(t *testing.T) {
+	for _, n := range []int{-1, 0, MAX_DRUG_NUMBER + 1} {
+		if id, err := DrugBankIDFromNumber(n); err == nil {
+			t.Errorf("DrugBankIDFromNumber(%d) = %q, want an error", n, id)
+		}
+	}
+	if id, err := DrugBankIDFromNumber(1); id != "DB00001" || err != nil {
+		t.Errorf("DrugBankIDFromNumber(1) = %q, %v, want DB00001", id, err)
+	}
+}
+
+func TestParseDrugBankIDRange(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []DrugBankID
+		wantErr bool
+	}{
+		{"DB00008..DB00011", []DrugBankID{"DB00008", "DB00009", "DB00010", "DB00011"}, false},
+		{"5..5", []DrugBankID{"DB00005"}, false},
+		{"DB00011..DB00008", nil, true},
+		{"0..DB00002", nil, true},
+		{"DB00000..DB00002", nil, true},
+		{"DB00001", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDrugBankIDRange(tt.raw)
+		if !slices.Equal(got, tt.want) || (err != nil) != tt.wantErr {
+			t.Errorf("ParseDrugBankIDRange(%q) = %q, %v, want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadDrugBankIDs(t *testing.T) {
+	input := "# approved antibiotics\nDB00001, db00002\t682\n\nDB00003 # trailing comment\n"
+	got, err := ReadDrugBankIDs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadDrugBankIDs(): %v", err)
+	}
+	if want := []DrugBankID{"DB00001", "DB00002", "DB00682", "DB00003"}; !slices.Equal(got, want) {
+		t.Errorf("ReadDrugBankIDs() = %q, want %q", got, want)
+	}
+
+	_, err = ReadDrugBankIDs(strings.NewReader("DB00001\nDB00002 aspirin\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("ReadDrugBankIDs() error = %v, want one for line 2", err)
+	}
+}
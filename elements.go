@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// Maximum difference between the scraped average mass and the one computed from the formula
+// before a drug is flagged, DrugBank rounds to three decimals and uses slightly older atomic weights.
+const (
+	WEIGHT_TOLERANCE_DA       = 0.5
+	WEIGHT_TOLERANCE_RELATIVE = 0.001
+)
+
+// Standard atomic weights (IUPAC, abridged to 4-5 significant decimals) used to
+// compute a drug's average mass from its chemical formula.
+var averageAtomicMass = map[string]float64{
+	"H": 1.008, "He": 4.0026, "Li": 6.94, "Be": 9.0122, "B": 10.81, "C": 12.011, "N": 14.007, "O": 15.999,
+	"F": 18.998, "Ne": 20.180, "Na": 22.990, "Mg": 24.305, "Al": 26.982, "Si": 28.085, "P": 30.974, "S": 32.06,
+	"Cl": 35.45, "Ar": 39.948, "K": 39.098, "Ca": 40.078, "Sc": 44.956, "Ti": 47.867, "V": 50.942, "Cr": 51.996,
+	"Mn": 54.938, "Fe": 55.845, "Co": 58.933, "Ni": 58.693, "Cu": 63.546, "Zn": 65.38, "Ga": 69.723, "Ge": 72.630,
+	"As": 74.922, "Se": 78.971, "Br": 79.904, "Kr": 83.798, "Rb": 85.468, "Sr": 87.62, "Y": 88.906, "Zr": 91.224,
+	"Nb": 92.906, "Mo": 95.95, "Tc": 98, "Ru": 101.07, "Rh": 102.91, "Pd": 106.42, "Ag": 107.87, "Cd": 112.41,
+	"In": 114.82, "Sn": 118.71, "Sb": 121.76, "Te": 127.60, "I": 126.90, "Xe": 131.29, "Cs": 132.91, "Ba": 137.33,
+	"La": 138.91, "Ce": 140.12, "Pr": 140.91, "Nd": 144.24, "Pm": 145, "Sm": 150.36, "Eu": 151.96, "Gd": 157.25,
+	"Tb": 158.93, "Dy": 162.50, "Ho": 164.93, "Er": 167.26, "Tm": 168.93, "Yb": 173.05, "Lu": 174.97, "Hf": 178.49,
+	"Ta": 180.95, "W": 183.84, "Re": 186.21, "Os": 190.23, "Ir": 192.22, "Pt": 195.08, "Au": 196.97, "Hg": 200.59,
+	"Tl": 204.38, "Pb": 207.2, "Bi": 208.98, "Po": 209, "At": 210, "Rn": 222, "Fr": 223, "Ra": 226,
+	"Ac": 227, "Th": 232.04, "Pa": 231.04, "U": 238.03, "Np": 237, "Pu": 244, "Am": 243, "Cm": 247,
+	// isotopes DrugBank writes as their own symbols in labelled compounds
+	"D": 2.0141, "T": 3.0160,
+}
+
+// formulaMass computes the average mass in Da of a Hill notation formula such as C9H8O4,
+// also accepting parenthesised groups like Ca(OH)2 and a trailing charge.
+func formulaMass(formula string) (float64, error) {
+	runes := []rune(formula)
+	pos := 0
+
+	readCount := func() int {
+		start := pos
+		for pos < len(runes) && unicode.IsDigit(runes[pos]) {
+			pos++
+		}
+		if start == pos {
+			return 1
+		}
+		n, _ := strconv.Atoi(string(runes[start:pos]))
+		return n
+	}
+
+	// stack of group masses, the bottom one is the whole formula
+	stack := []float64{0}
+	for pos < len(runes) {
+		r := runes[pos]
+		switch {
+		case unicode.IsUpper(r):
+			symbol := string(r)
+			pos++
+			if pos < len(runes) && unicode.IsLower(runes[pos]) {
+				symbol += string(runes[pos])
+				pos++
+			}
+			mass, known := averageAtomicMass[symbol]
+			if !known {
+				return 0, fmt.Errorf("formulaMass(): unknown element %q in %q", symbol, formula)
+			}
+			stack[len(stack)-1] += mass * float64(readCount())
+		case r == '(' || r == '[':
+			stack = append(stack, 0)
+			pos++
+		case r == ')' || r == ']':
+			if len(stack) == 1 {
+				return 0, fmt.Errorf("formulaMass(): unbalanced parenthesis in %q", formula)
+			}
+			pos++
+			group := stack[len(stack)-1] * float64(readCount())
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1] += group
+		case r == '+' || r == '-':
+			// charges don't change the mass noticeably, anything after them is part of the charge
+			pos = len(runes)
+		case unicode.IsSpace(r):
+			pos++
+		default:
+			return 0, fmt.Errorf("formulaMass(): unexpected %q in %q", r, formula)
+		}
+	}
+	if len(stack) != 1 {
+		return 0, fmt.Errorf("formulaMass(): unbalanced parenthesis in %q", formula)
+	}
+	return stack[0], nil
+}
+
+// massToDa converts a scraped mass to Da, reporting false for units it doesn't know.
+func massToDa(weight MolWeight) (float64, bool) {
+	switch weight.Units {
+	case "Da", "g/mol", "":
+		return weight.Weight, true
+	case "kDa":
+		return weight.Weight * 1000, true
+	}
+	return 0, false
+}
+
+// weightMatchesFormula compares a scraped average mass with the one computed from the formula.
+func weightMatchesFormula(average float64, computed float64) bool {
+	diff := math.Abs(average - computed)
+	return diff <= WEIGHT_TOLERANCE_DA || diff <= computed*WEIGHT_TOLERANCE_RELATIVE
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// FoodInteraction is one entry of the Food Interactions list, Category is derived from the
+// text and "other" when it doesn't match a known guidance.
+type FoodInteraction struct {
+	Category string `json:"category"` // e.g. "avoid_alcohol", "take_with_food", "avoid_grapefruit"
+	Text     string `json:"text"`
+}
+
+// foodInteractionCategories are tried in order, the first to match the text wins, so the more
+// specific phrasings come before the general ones.
+var foodInteractionCategories = []struct {
+	Category string
+	Pattern  *regexp.Regexp
+}{
+	{"take_with_or_without_food", regexp.MustCompile(`(?i)\bwith or without (?:food|meals?)\b`)},
+	{"avoid_grapefruit", regexp.MustCompile(`(?i)\bgrapefruit|\bseville orange|\bpomelo`)},
+	{"avoid_alcohol", regexp.MustCompile(`(?i)\balcohol|\bethanol`)},
+	{"avoid_st_johns_wort", regexp.MustCompile(`(?i)\bst\.? ?john'?s[ -]wort`)},
+	// "1 hour before or 2 hours after meals" keeps the drug away from food
+	{"take_on_empty_stomach", regexp.MustCompile(`(?i)\bempty stomach|\bwithout food|\bbefore (?:a )?(?:meals?|food|eating)|\b[0-9]+ (?:hours?|minutes?) after (?:a )?(?:meals?|food|eating)`)},
+	{"avoid_high_fat_meals", regexp.MustCompile(`(?i)\bhigh[- ]fat`)},
+	{"take_with_food", regexp.MustCompile(`(?i)\b(?:take|administer|given) with (?:food|a meal|meals|a snack)|\bwith food\b|\bafter (?:a )?(?:meals?|food|eating)`)},
+	{"limit_caffeine", regexp.MustCompile(`(?i)\bcaffeine|\bcoffee|\bxanthine`)},
+	{"separate_from_multivalent_cations", regexp.MustCompile(`(?i)\bantacids?|\biron\b|\bmagnesium|\bzinc\b|\bmultivalent|\bpolyvalent`)},
+	{"avoid_dairy_and_calcium", regexp.MustCompile(`(?i)\bdairy|\bmilk\b|\bcalcium`)},
+	{"limit_potassium", regexp.MustCompile(`(?i)\bpotassium`)},
+	{"limit_vitamin_k", regexp.MustCompile(`(?i)\bvitamin k\b|\bleafy green`)},
+	{"avoid_tyramine", regexp.MustCompile(`(?i)\btyramine`)},
+	{"limit_sodium", regexp.MustCompile(`(?i)\bsodium\b|\bsalt\b`)},
+	// after the minerals, "calcium supplements" and "potassium supplements" aren't herbal
+	{"avoid_herbal_supplements", regexp.MustCompile(`(?i)\bherbs?\b|\bherbal\b`)},
+	{"stay_hydrated", regexp.MustCompile(`(?i)\bdrink plenty|\bfluids?\b|\bhydrat`)},
+}
+
+// foodInteractionCategory derives the normalized category of a food interaction text.
+func foodInteractionCategory(text string) string {
+	for _, rule := range foodInteractionCategories {
+		if rule.Pattern.MatchString(text) {
+			return rule.Category
+		}
+	}
+	return "other"
+}
+
+func handleFoodInteractions(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleFoodInteractions(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleFoodInteractions(): invalid arguments")
+	}
+
+	items := sibling.Find("li")
+	if items.Length() == 0 {
+		items = sibling
+	}
+	items.Each(func(_ int, item *goquery.Selection) {
+		text := normalizeFieldValue("", item.Text())
+		if text == "" || strings.EqualFold(text, "not available") {
+			return
+		}
+		json.FoodInteractions = append(json.FoodInteractions, FoodInteraction{
+			Category: foodInteractionCategory(text),
+			Text:     text,
+		})
+	})
+	return nil
+}
+
+// foodInteractionCategoryList lists the distinct food interaction categories of a drug.
+func foodInteractionCategoryList(drugInfo DrugInfo) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, interaction := range drugInfo.FoodInteractions {
+		if !seen[interaction.Category] {
+			seen[interaction.Category] = true
+			categories = append(categories, interaction.Category)
+		}
+	}
+	return categories
+}
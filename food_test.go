@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFoodInteractionCategory(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Take with or without food.", "take_with_or_without_food"},
+		{"Avoid grapefruit products.", "avoid_grapefruit"},
+		{"Avoid alcohol.", "avoid_alcohol"},
+		{"Avoid St. John's Wort.", "avoid_st_johns_wort"},
+		{"Avoid herbs and supplements with anticoagulant/antiplatelet activity.", "avoid_herbal_supplements"},
+		{"Take on an empty stomach.", "take_on_empty_stomach"},
+		{"Take 1 hour before or 2 hours after meals.", "take_on_empty_stomach"},
+		{"Take before a meal.", "take_on_empty_stomach"},
+		{"Take with food.", "take_with_food"},
+		{"Take after a meal.", "take_with_food"},
+		{"Take with or after meals.", "take_with_food"},
+		{"Avoid high-fat meals.", "avoid_high_fat_meals"},
+		{"Limit caffeine intake.", "limit_caffeine"},
+		{"Take separate from antacids, iron, zinc and calcium supplements.", "separate_from_multivalent_cations"},
+		{"Avoid milk and dairy products.", "avoid_dairy_and_calcium"},
+		{"Avoid potassium supplements.", "limit_potassium"},
+		{"Maintain a consistent intake of vitamin K.", "limit_vitamin_k"},
+		{"Avoid tyramine rich foods.", "avoid_tyramine"},
+		{"Limit salt intake.", "limit_sodium"},
+		{"Drink plenty of fluids.", "stay_hydrated"},
+		{"Exercise caution with licorice.", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := foodInteractionCategory(tt.text); got != tt.want {
+			t.Errorf("foodInteractionCategory(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Interaction is one row of a drug's interactions table. Effect and Property are
+// parsed from the description and left empty when it doesn't follow a known phrasing.
+type Interaction struct {
+	PartnerID   string `json:"partner_id"`
+	PartnerName string `json:"partner_name"`
+	Description string `json:"description"`
+	Effect      string `json:"effect,omitempty"`   // "increase" or "decrease"
+	Property    string `json:"property,omitempty"` // e.g. "serum concentration", "risk or severity of adverse effects"
+}
+
+var (
+	interactionLinkRegexp = regexp.MustCompile(`<a href="/drugs/([^"]+)">([^<]+)</a>`)
+
+	// "The serum concentration of X can be increased when it is combined with Y."
+	// "The risk or severity of adverse effects can be increased when X is combined with Y."
+	interactionCanBeRegexp = regexp.MustCompile(`^(?:The )?(.+?) can be (increased|decreased)\b`)
+	// "X may decrease the excretion rate of Y which could result in a higher serum level."
+	interactionMayRegexp = regexp.MustCompile(`\bmay (increase|decrease) the (.+?) of `)
+	// "X can cause a decrease in the absorption of Y resulting in a reduced serum concentration."
+	interactionCauseRegexp = regexp.MustCompile(`\b(?:an? )?(increase|decrease) in the (.+?) of `)
+	// trailing " of <drug name>" in "serum concentration of Abacavir", drug names are capitalized
+	interactionSubjectRegexp = regexp.MustCompile(` of [A-Z0-9(].*$`)
+	// "serum concentration of the active metabolites" is still the serum concentration
+	interactionMetabolitesRegexp = regexp.MustCompile(` of (?:the |its )?(?:active )?metabolites?$`)
+)
+
+// parseInteractionEffect extracts the effect direction and the affected property from an interaction description.
+func parseInteractionEffect(description string) (effect string, property string) {
+	description = strings.TrimSpace(description)
+
+	if m := interactionCanBeRegexp.FindStringSubmatch(description); m != nil {
+		property = m[1]
+		// "risk or severity of CNS depression" names the effect itself, not a drug
+		if !strings.HasPrefix(property, "risk or severity of ") {
+			property = interactionSubjectRegexp.ReplaceAllString(property, "")
+			property = interactionMetabolitesRegexp.ReplaceAllString(property, "")
+		}
+		return strings.TrimSuffix(m[2], "d"), strings.ToLower(property)
+	}
+	if m := interactionMayRegexp.FindStringSubmatch(description); m != nil {
+		return m[1], strings.ToLower(m[2])
+	}
+	if m := interactionCauseRegexp.FindStringSubmatch(description); m != nil {
+		return m[1], strings.ToLower(m[2])
+	}
+	return "", ""
+}
+
+// UnmarshalJSON also accepts the [id, name, description] arrays of results written before
+// interactions were typed, so older results files can still be loaded and diffed.
+func (i *Interaction) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		var row []string
+		if err := json.Unmarshal(data, &row); err != nil {
+			return err
+		}
+		if len(row) >= 3 {
+			i.PartnerID, i.PartnerName, i.Description = row[0], row[1], row[2]
+			i.Effect, i.Property = parseInteractionEffect(i.Description)
+		}
+		return nil
+	}
+
+	type plain Interaction
+	return json.Unmarshal(data, (*plain)(i))
+}
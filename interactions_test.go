@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestParseInteractionEffect(t *testing.T) {
+	tests := []struct {
+		description  string
+		wantEffect   string
+		wantProperty string
+	}{
+		{"The serum concentration of Abacavir can be increased when it is combined with Lepirudin.", "increase", "serum concentration"},
+		{"The risk or severity of adverse effects can be increased when Lepirudin is combined with Apixaban.", "increase", "risk or severity of adverse effects"},
+		{"The risk or severity of CNS depression can be decreased when X is combined with Y.", "decrease", "risk or severity of cns depression"},
+		{"Abacavir may decrease the excretion rate of Lepirudin which could result in a higher serum level.", "decrease", "excretion rate"},
+		{"Aluminium hydroxide can cause a decrease in the absorption of Lepirudin resulting in a reduced serum concentration.", "decrease", "absorption"},
+		{"The serum concentration of the active metabolites of Codeine can be decreased when Codeine is combined with Quinidine.", "decrease", "serum concentration"},
+		{"The serum concentration of the active metabolite of Clopidogrel can be increased when it is combined with X.", "increase", "serum concentration"},
+		{"The metabolism of 5-Fluorouracil can be decreased when combined with Y.", "decrease", "metabolism"},
+		{"The therapeutic efficacy of (R)-warfarin can be increased when used in combination with Y.", "increase", "therapeutic efficacy"},
+		{"Abacavir may increase the serum concentration of the active metabolites of Lepirudin.", "increase", "serum concentration"},
+		{"Lepirudin is an interacting drug.", "", ""},
+	}
+
+	for _, tt := range tests {
+		effect, property := parseInteractionEffect(tt.description)
+		if effect != tt.wantEffect || property != tt.wantProperty {
+			t.Errorf("parseInteractionEffect(%q) = %q, %q, want %q, %q", tt.description, effect, property, tt.wantEffect, tt.wantProperty)
+		}
+	}
+}
+
+func TestParseDrugInteractions(t *testing.T) {
+	page := `{"recordsTotal": 3, "recordsFiltered": 3, "data": [
+		["<a href=\"/drugs/DB00006\">Bivalirudin</a>", "The risk or severity of bleeding can be increased when Lepirudin is combined with Bivalirudin."],
+		["no link here", "The serum concentration of X can be decreased when it is combined with Y."],
+		["<a href=\"/drugs/DB00007\">Leuprolide</a>"]
+	]}`
+
+	interactions, warnings, total, err := ParseDrugInteractions(page)
+	if err != nil {
+		t.Fatalf("ParseDrugInteractions(): %v", err)
+	}
+	if total != 3 {
+		t.Errorf("recordsTotal = %d, want 3", total)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("got %d warnings, want 2: %q", len(warnings), warnings)
+	}
+	if len(interactions) != 1 {
+		t.Fatalf("got %d interactions, want 1", len(interactions))
+	}
+	want := Interaction{
+		PartnerID:   "DB00006",
+		PartnerName: "Bivalirudin",
+		Description: "The risk or severity of bleeding can be increased when Lepirudin is combined with Bivalirudin.",
+		Effect:      "increase",
+		Property:    "risk or severity of bleeding",
+	}
+	if interactions[0] != want {
+		t.Errorf("got %+v, want %+v", interactions[0], want)
+	}
+
+	if _, _, _, err := ParseDrugInteractions("<html>"); err == nil {
+		t.Error("ParseDrugInteractions() accepted a non JSON page")
+	}
+}
+
+func TestInteractionUnmarshalLegacyRow(t *testing.T) {
+	var interaction Interaction
+	if err := interaction.UnmarshalJSON([]byte(`["DB00006", "Bivalirudin", "Abacavir may increase the anticoagulant activities of Bivalirudin."]`)); err != nil {
+		t.Fatalf("UnmarshalJSON(): %v", err)
+	}
+	if interaction.PartnerID != "DB00006" || interaction.Effect != "increase" || interaction.Property != "anticoagulant activities" {
+		t.Errorf("got %+v", interaction)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	LISTING_URL = "https://go.drugbank.com/drugs"
+	// what the last runs saw in the pagination of every filter, kept in the --out directory
+	LISTING_CACHE_FILE = "listing.json"
+)
+
+// DrugGroup is a drug group checkbox of the listing page filter.
+type DrugGroup string
+
+const (
+	GroupApproved        DrugGroup = "approved"
+	GroupNutraceutical   DrugGroup = "nutraceutical"
+	GroupIllicit         DrugGroup = "illicit"
+	GroupInvestigational DrugGroup = "investigational"
+	GroupWithdrawn       DrugGroup = "withdrawn"
+	GroupExperimental    DrugGroup = "experimental"
+)
+
+// Region is a market checkbox of the listing page filter.
+type Region string
+
+const (
+	RegionUS Region = "us"
+	RegionCA Region = "ca"
+	RegionEU Region = "eu"
+)
+
+// the checkboxes of the filter form on the listing page
+var (
+	listingGroups  = []DrugGroup{GroupApproved, GroupNutraceutical, GroupIllicit, GroupInvestigational, GroupWithdrawn, GroupExperimental}
+	listingRegions = []Region{RegionUS, RegionCA, RegionEU}
+)
+
+// ListingFilter selects the drugs of the /drugs listing pages. A drug is listed when it is
+// in any of the Groups and marketed in any of the Regions, empty lists don't filter at all.
+type ListingFilter struct {
+	Groups  []DrugGroup `json:"groups,omitempty"`
+	Regions []Region    `json:"regions,omitempty"`
+}
+
+// ParseListingFilter reads comma separated group and region lists such as "approved,withdrawn" and "eu".
+func ParseListingFilter(groupList string, regionList string) (ListingFilter, error) {
+	var filter ListingFilter
+	for _, raw := range strings.Split(groupList, ",") {
+		group := DrugGroup(strings.ToLower(strings.TrimSpace(raw)))
+		if group == "" {
+			continue
+		}
+		if !slices.Contains(listingGroups, group) {
+			return filter, fmt.Errorf("unknown drug group %q, expected one of %v", raw, listingGroups)
+		}
+		filter.Groups = append(filter.Groups, group)
+	}
+	for _, raw := range strings.Split(regionList, ",") {
+		region := Region(strings.ToLower(strings.TrimSpace(raw)))
+		if region == "" {
+			continue
+		}
+		if !slices.Contains(listingRegions, region) {
+			return filter, fmt.Errorf("unknown region %q, expected one of %v", raw, listingRegions)
+		}
+		filter.Regions = append(filter.Regions, region)
+	}
+	return filter, nil
+}
+
+// URL returns the link to the given listing page of the filtered drugs.
+func (filter ListingFilter) URL(page int) string {
+	query := url.Values{}
+	for _, group := range listingGroups {
+		query.Set(string(group), checkbox(slices.Contains(filter.Groups, group)))
+	}
+	for _, region := range listingRegions {
+		query.Set(string(region), checkbox(slices.Contains(filter.Regions, region)))
+	}
+	query.Set("commit", "Apply Filter")
+	query.Set("page", strconv.Itoa(page))
+	return LISTING_URL + "?" + query.Encode()
+}
+
+// String describes the filter for logs, "all drugs" when it doesn't filter.
+func (filter ListingFilter) String() string {
+	var parts []string
+	if len(filter.Groups) > 0 {
+		parts = append(parts, fmt.Sprintf("groups %v", filter.Groups))
+	}
+	if len(filter.Regions) > 0 {
+		parts = append(parts, fmt.Sprintf("regions %v", filter.Regions))
+	}
+	if len(parts) == 0 {
+		return "all drugs"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "1"
+	}
+	return "0"
+}
+
+// ListingSize is the size of a filtered listing as shown by the pagination of its first page.
+type ListingSize struct {
+	Pages   int   `json:"pages"`
+	Drugs   int   `json:"drugs,omitempty"` // 0 when the page didn't show a total
+	Checked int64 `json:"checked"`
+}
+
+// the filter form shown on every listing page, also when the filter matched no drugs
+const listingFilterFormSelector = `form[action$="/drugs"] input[name="commit"]`
+
+// "Displaying drugs 1 - 25 of 16581 in total"
+var listingTotalRegexp = regexp.MustCompile(`of\s+([0-9][0-9,]*)\s+in total`)
+
+// DetectListingSize fetches the first listing page of filter and reads its pagination. The page
+// is returned too, so its drug links don't have to be fetched again.
+func DetectListingSize(filter ListingFilter) (ListingSize, *goquery.Document, error) {
+	_, page, err := fetchPage(filter.URL(1))
+	if err != nil {
+		return ListingSize{}, nil, fmt.Errorf("DetectListingSize(): %v", err)
+	}
+	size, err := parseListingSize(page)
+	return size, page, err
+}
+
+// parseListingSize reads the highest page number linked from the pagination, a listing without
+// pagination has a single page. A listing page that shows the filter form but no drugs table
+// matched nothing and has 0 pages. Fails on pages with neither, so a block page or an error
+// page doesn't pass for an empty listing.
+func parseListingSize(page *goquery.Document) (ListingSize, error) {
+	size := ListingSize{Pages: 1, Checked: time.Now().Unix()}
+	if page.Find("#drugs-table").Length() == 0 {
+		if page.Find(listingFilterFormSelector).Length() == 0 {
+			return size, fmt.Errorf("parseListingSize(): neither a drugs table nor the filter form on the listing page")
+		}
+		size.Pages = 0
+		return size, nil
+	}
+
+	page.Find(".pagination a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		link, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		if n, err := strconv.Atoi(link.Query().Get("page")); err == nil && n > size.Pages {
+			size.Pages = n
+		}
+	})
+
+	if m := listingTotalRegexp.FindStringSubmatch(normalizeFieldValue("", page.Text())); m != nil {
+		size.Drugs, _ = strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+	}
+	return size, nil
+}
+
+// lastListingPage returns the last listing page of filter, 0 when no drug matches it and
+// MAX_PAGE when it can't be detected, and the drug links of the first page when it was read.
+// The size is compared with the one cached in outDir by the previous run, and a warning is
+// printed when the catalogue grew or shrank since.
+func lastListingPage(filter ListingFilter, outDir string) (int, []DrugLink) {
+	size, page, err := DetectListingSize(filter)
+	if err != nil {
+		log.Printf("🔥 Failed to detect the number of listing pages, assuming %d: %v\n", MAX_PAGE, err)
+		return MAX_PAGE, nil
+	}
+	firstPage := getLinksPerPage(page)
+	fmt.Printf("ℹ️ %s: %d listing pages, %d drugs\n", filter, size.Pages, size.Drugs)
+
+	path := filepath.Join(outDir, LISTING_CACHE_FILE)
+	cache := make(map[string]ListingSize)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &cache); err != nil {
+			log.Printf("🔥 Ignoring bad listing cache %s: %v\n", path, err)
+		}
+	}
+
+	// the first page URL is the same for equal filters whatever order their lists were given in
+	key := filter.URL(1)
+	if previous, ok := cache[key]; ok && (previous.Pages != size.Pages || (previous.Drugs != 0 && size.Drugs != 0 && previous.Drugs != size.Drugs)) {
+		fmt.Printf("⚠️ The catalogue changed since the run of %s: %d pages and %d drugs then, %d pages and %d drugs now\n",
+			time.Unix(previous.Checked, 0).Format(time.DateTime), previous.Pages, previous.Drugs, size.Pages, size.Drugs)
+	}
+
+	cache[key] = size
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		log.Printf("🔥 Error writing listing cache: %v\n", err)
+		return size.Pages, firstPage
+	}
+	if data, err := json.MarshalIndent(cache, "", "    "); err == nil {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Printf("🔥 Error writing listing cache: %v\n", err)
+		}
+	}
+	return size.Pages, firstPage
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseListingSize(t *testing.T) {
+	const form = `<form action="/drugs" method="get"><input type="checkbox" name="approved" value="1"><input type="submit" name="commit" value="Apply Filter"></form>`
+	tests := []struct {
+		name      string
+		html      string
+		wantPages int
+		wantDrugs int
+		wantErr   bool
+	}{
+		{"paginated", form + `<table id="drugs-table"><tr><td>DB00001</td></tr></table>
+			<div class="page_info">Displaying drugs <b>1&nbsp;-&nbsp;25</b> of <b>16,581</b> in total</div>
+			<ul class="pagination"><li><a href="/drugs?page=2">2</a></li><li><a href="/drugs?approved=1&amp;page=664">Last »</a></li><li><a href="/drugs?page=3">Next ›</a></li></ul>`, 664, 16581, false},
+		{"single page", form + `<table id="drugs-table"><tr><td>DB00001</td></tr></table>
+			<div>Displaying <b>all 3</b> drugs</div>`, 1, 0, false},
+		{"empty listing", form + `<div class="no-results">No drugs found</div>`, 0, 0, false},
+		{"block page", `<html><title>Just a moment...</title><body>Checking your browser</body></html>`, 0, 0, true},
+		{"form elsewhere", `<form action="/login"><input type="submit" name="commit" value="Sign in"></form>`, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			size, err := parseListingSize(page)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListingSize() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (size.Pages != tt.wantPages || size.Drugs != tt.wantDrugs) {
+				t.Errorf("parseListingSize() = %d pages, %d drugs, want %d pages, %d drugs", size.Pages, size.Drugs, tt.wantPages, tt.wantDrugs)
+			}
+		})
+	}
+}
+
+func TestParseListingFilter(t *testing.T) {
+	tests := []struct {
+		groups, regions string
+		want            string
+		wantErr         bool
+	}{
+		{"", "", "all drugs", false},
+		{"Approved, withdrawn", "", "groups [approved withdrawn]", false},
+		{"", "eu,,us", "regions [eu us]", false},
+		{"approved", "ca", "groups [approved], regions [ca]", false},
+		{"generic", "", "", true},
+		{"", "uk", "", true},
+	}
+	for _, tt := range tests {
+		filter, err := ParseListingFilter(tt.groups, tt.regions)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseListingFilter(%q, %q) error = %v, want error %v", tt.groups, tt.regions, err, tt.wantErr)
+			continue
+		}
+		if err == nil && filter.String() != tt.want {
+			t.Errorf("ParseListingFilter(%q, %q) = %s, want %s", tt.groups, tt.regions, filter, tt.want)
+		}
+	}
+}
+
+func TestListingFilterURL(t *testing.T) {
+	a, _ := ParseListingFilter("withdrawn,approved", "eu")
+	b, _ := ParseListingFilter("approved,withdrawn", "eu")
+	if a.URL(1) != b.URL(1) {
+		t.Errorf("equal filters give different URLs %q and %q", a.URL(1), b.URL(1))
+	}
+
+	link, err := url.Parse(a.URL(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := link.Query()
+	want := map[string]string{"approved": "1", "withdrawn": "1", "illicit": "0", "eu": "1", "us": "0", "page": "7", "commit": "Apply Filter"}
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Errorf("%s=%q in %s, want %q", key, query.Get(key), link, value)
+		}
+	}
+}
+
+func TestCollectPageLinksReusesFetchedPages(t *testing.T) {
+	journal, err := OpenJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	pool := NewWorkerPool(1, 1, 1)
+	defer pool.Close()
+
+	// page 1 was read while detecting the page count, nothing is fetched
+	first := []DrugLink{{Name: "Lepirudin", Link: "https://go.drugbank.com/drugs/DB00001"}}
+	links := collectPageLinks(pool, journal, ListingFilter{}, []int{1}, map[int][]DrugLink{1: first})
+	if !slices.Equal(links, first) {
+		t.Errorf("collectPageLinks() = %v, want %v", links, first)
+	}
+	if journaled, done := journal.CompletedPage(1); !done || !slices.Equal(journaled, first) {
+		t.Errorf("page 1 journaled as %v, %v", journaled, done)
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"os"
@@ -24,13 +27,13 @@ import (
 )
 
 const (
-	MAX_PAGE                = 508
-	BASE_URL                = "https://go.drugbank.com/drugs?approved=0&nutraceutical=0&illicit=0&investigational=0&withdrawn=0&experimental=0&us=0&ca=0&eu=0&commit=Apply+Filter&page="
+	MAX_PAGE                = 508 // fallback when the pagination of the listing can't be read
 	STUB_NOTICE_TEXT        = "this drug entry is a stub and has not been fully annotated. it is scheduled to be annotated soon."
 	DRUG_INTERACTIONS_URL   = "https://go.drugbank.com/drugs/%s/drug_interactions.json?start=%d&length=%d&_=%d"
 	DRUG_BASE_URL           = "https://go.drugbank.com/drugs/%s/"
 	STRUCTURE_SVG_URL       = "https://go.drugbank.com/structures/%s/image.svg"
 	STRUCTURE_THUMB_SVG_URL = "https://go.drugbank.com/structures/%s/thumb.svg"
+	STRUCTURE_MOL_URL       = "https://go.drugbank.com/structures/small_molecule_drugs/%s.mol"
 )
 
 var (
@@ -42,12 +45,32 @@ var (
 	stats_num_errors             int           = 0
 	stats_num_requests           int           = 0
 	stats_num_retry              int           = 0
+	stats_num_throttled          int           = 0
+
+	// guards the stats_ globals, fetches run on many workers at once
+	stats_mu sync.Mutex
 )
 
-const (
-	RetryLimit           = 4
-	DelayBetweenRequests = 8 * time.Second  // Adjust as needed
-	DelayAfterError      = 20 * time.Second // Delay after an error
+func addStat(counter *int) {
+	stats_mu.Lock()
+	*counter++
+	stats_mu.Unlock()
+}
+
+func addStatLog(entries *[]string, entry string) {
+	stats_mu.Lock()
+	*entries = append(*entries, entry)
+	stats_mu.Unlock()
+}
+
+const RetryLimit = 4
+
+// Retry backoff and page sizes, overridable from the CLI. The pause between requests is up
+// to the rate limiter, failed requests wait a random time between both delays.
+var (
+	DelayBetweenRequests = 8 * time.Second  // lower bound of the backoff, --error-delay-min
+	DelayAfterError      = 20 * time.Second // upper bound of the backoff, --error-delay
+	InteractionsPageSize = 100              // rows per drug_interactions.json request
 )
 
 func getFieldInfo(drugInfo *DrugInfo, fieldName string, opts ...string) string {
@@ -82,9 +105,17 @@ type (
 		Link string `json:"link,omitempty"`
 	}
 
+	PageLinks struct {
+		Page  int
+		Links []DrugLink
+	}
+
 	Weights struct {
 		Average      MolWeight `json:"average"`
 		Monoisotopic MolWeight `json:"monoisotopic"`
+		// average mass computed from the formula, and whether it disagrees with Average
+		FormulaMass     float64 `json:"formula_mass,omitempty"`
+		FormulaMismatch bool    `json:"formula_mismatch,omitempty"`
 	}
 
 	InChiData struct {
@@ -99,35 +130,60 @@ type (
 	}
 
 	DrugInfo struct {
-		Smiles               string              `json:"smiles,omitempty"`
-		ID                   string              `json:"id,omitempty"`
-		Molecule             string              `json:"molecule,omitempty"`
-		CAS                  string              `json:"cas,omitempty"`
-		IupacName            string              `json:"iupac_name,omitempty"`
-		Background           string              `json:"background,omitempty"`
-		InChI                InChiData           `json:"inchi,omitempty"`
-		InChIKey             string              `json:"inchi_key,omitempty"`
-		Summary              string              `json:"summary,omitempty"`
-		Weight               []MolWeight         `json:"weight,omitempty"`
-		Formula              string              `json:"formula,omitempty"`
-		Description          string              `json:"description,omitempty"`
-		Categories           []string            `json:"categories,omitempty"`
-		Link                 string              `json:"link,omitempty"`
-		Type                 string              `json:"type,omitempty"`
-		Groups               []string            `json:"groups,omitempty"`
-		Synonyms             []string            `json:"synonyms,omitempty"`
-		Indication           string              `json:"indication,omitempty"`
-		IsStub               bool                `json:"is_stub,omitempty"`
-		Pharmacodynamics     string              `json:"pharmacodynamics,omitempty"`
-		Moa                  []map[string]string `json:"moa,omitempty"`
-		AdverseEffects       string              `json:"adverse_effects,omitempty"`
-		DrugInteractions     [][]string          `json:"drug_interactions,omitempty"`
-		DrugInteractionsPage []string            `json:"drug_interactions_page,omitempty"`
-		HalfLife             string              `json:"half_life,omitempty"`
-		RouteOfElimination   string              `json:"route_of_elimination,omitempty"`
-		Toxicity             string              `json:"toxicity,omitempty"`
-		Clearance            string              `json:"clearance,omitempty"`
-		Absorption           string              `json:"absorption,omitempty"`
+		Smiles                   string              `json:"smiles,omitempty"`
+		ID                       string              `json:"id,omitempty"`
+		Molecule                 string              `json:"molecule,omitempty"`
+		CAS                      string              `json:"cas,omitempty"`
+		IupacName                string              `json:"iupac_name,omitempty"`
+		Background               string              `json:"background,omitempty"`
+		InChI                    InChiData           `json:"inchi,omitempty"`
+		InChIKey                 string              `json:"inchi_key,omitempty"`
+		Summary                  string              `json:"summary,omitempty"`
+		Weight                   []MolWeight         `json:"weight,omitempty"`
+		Weights                  *Weights            `json:"weights,omitempty"`
+		Formula                  string              `json:"formula,omitempty"`
+		Description              string              `json:"description,omitempty"`
+		Categories               []string            `json:"categories,omitempty"`
+		DrugCategories           []DrugCategory      `json:"drug_categories,omitempty"`
+		ATCCodes                 []ATCCode           `json:"atc_codes,omitempty"`
+		CrossReferences          CrossReferences     `json:"cross_references,omitempty"`
+		ExperimentalProperties   []ChemicalProperty  `json:"experimental_properties,omitempty"`
+		PredictedProperties      []ChemicalProperty  `json:"predicted_properties,omitempty"`
+		Taxonomy                 *ChemicalTaxonomy   `json:"taxonomy,omitempty"`
+		FoodInteractions         []FoodInteraction   `json:"food_interactions,omitempty"`
+		References               []Reference         `json:"references,omitempty"`
+		ClinicalTrials           *ClinicalTrials     `json:"clinical_trials,omitempty"`
+		Citations                map[string][]string `json:"citations,omitempty"` // json field name -> reference keys cited in it
+		Assets                   []Asset             `json:"assets,omitempty"`
+		Link                     string              `json:"link,omitempty"`
+		Type                     string              `json:"type,omitempty"`
+		Groups                   []string            `json:"groups,omitempty"`
+		Synonyms                 []string            `json:"synonyms,omitempty"`
+		Indication               string              `json:"indication,omitempty"`
+		IsStub                   bool                `json:"is_stub,omitempty"`
+		Pharmacodynamics         string              `json:"pharmacodynamics,omitempty"`
+		Moa                      []map[string]string `json:"moa,omitempty"`
+		AdverseEffects           string              `json:"adverse_effects,omitempty"`
+		DrugInteractions         []Interaction       `json:"drug_interactions,omitempty"`
+		InteractionWarnings      []string            `json:"interaction_warnings,omitempty"`
+		DrugInteractionsPage     []string            `json:"drug_interactions_page,omitempty"`
+		DrugInteractionsTotal    int                 `json:"drug_interactions_total,omitempty"`
+		DrugInteractionsComplete *bool               `json:"drug_interactions_complete,omitempty"` // nil when the page has no interactions table
+		HalfLife                 string              `json:"half_life,omitempty"`
+		RouteOfElimination       string              `json:"route_of_elimination,omitempty"`
+		Toxicity                 string              `json:"toxicity,omitempty"`
+		Clearance                string              `json:"clearance,omitempty"`
+		Absorption               string              `json:"absorption,omitempty"`
+		VolumeOfDistribution     string              `json:"volume_of_distribution,omitempty"`
+		ProteinBinding           string              `json:"protein_binding,omitempty"`
+		Pharmacokinetics         *Pharmacokinetics   `json:"pharmacokinetics,omitempty"`
+		BioInteractors           []BioInteractor     `json:"bio_interactors,omitempty"`
+		Products                 []Product           `json:"products,omitempty"`
+		InternationalBrands      []Brand             `json:"international_brands,omitempty"`
+		Packagers                []string            `json:"packagers,omitempty"`
+		Manufacturers            []string            `json:"manufacturers,omitempty"`
+		DosageForms              []DosageForm        `json:"dosage_forms,omitempty"`
+		Prices                   []Price             `json:"prices,omitempty"`
 	}
 )
 
@@ -197,76 +253,107 @@ func Sleep(duration time.Duration) {
 	s := spinner.New(spinner.CharSets[34], 100*time.Millisecond)
 	s.Color("fgCyan", "magenta", "bold")
 	s.Start()
-	stats_num_sleeps++
+	addStat(&stats_num_sleeps)
 	time.Sleep(duration)
 	s.Stop()
 }
 
-func fetchPage(url string, getDom ...bool) (string, *goquery.Document, error) {
-	delay := randTime(0, DelayBetweenRequests)
+// errPageNotFound is returned by fetchPage and fetchBytes for pages that don't exist, they aren't retried.
+var errPageNotFound = errors.New("page not found")
+
+// fetchBytes requests url through the shared rate limiter and returns the body. Failed
+// requests are retried, and a 429, a 403 (how Cloudflare challenges) or a Cloudflare ban
+// page slow the limiter down first.
+func fetchBytes(url string) ([]byte, error) {
 	delayErr := randTime(DelayBetweenRequests, DelayAfterError)
 
-	stats_num_requests++
+	addStat(&stats_num_requests)
 
 	for i := 0; i < RetryLimit; i++ {
+		limiter.Wait()
 		resp, err := http.Get(url)
 		if err != nil {
 			logFetchError(err, fmt.Sprintf("Error fetching URL: %v, retrying...", url))
 			clearTerminal()
 			Sleep(delayErr)
-			stats_num_retry++
+			addStat(&stats_num_retry)
 			continue
 		}
-		defer resp.Body.Close()
 
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, errPageNotFound
+		case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusForbidden:
+			logFetchError(err, fmt.Sprintf("%s: %v, slowing down to %.2f req/s...", resp.Status, url, throttle(resp, delayErr)))
+			addStat(&stats_num_retry)
+			continue
+		case err != nil:
 			logFetchError(err, fmt.Sprintf("Error reading response body: %v, retrying...", url))
 			Sleep(delayErr)
-			stats_num_retry++
+			addStat(&stats_num_retry)
 			continue
-		}
-		body := string(bodyBytes)
-		if strings.Contains(body, "error code: 1015") {
+		case bytes.Contains(body, []byte("error code: 1015")):
 			// terrible emoji disaster probably banned panic message
 			logFetchError(err, fmt.Sprintf("💀🩸💀🩸💀🩸💀🩸💀 --- [!!DEATH IS UPON US, CLOUDFLARE BANNED!!] --- 💀🩸💀🩸💀🩸💀🩸💀\n%s", body))
-			stats_num_retry++
-			Sleep(delayErr)
+			throttle(resp, delayErr)
+			addStat(&stats_num_retry)
 			continue
-		} else if strings.Contains(body, "page not found") {
-			logFetchError(err, "💀🩸💀🩸💀🩸💀🩸💀 PAGE NOT FOUND 404 💀🩸💀🩸💀🩸💀🩸💀")
-			stats_num_retry++
+		case resp.StatusCode != http.StatusOK:
+			logFetchError(err, fmt.Sprintf("Error fetching URL: %v (%s), retrying...", url, resp.Status))
 			Sleep(delayErr)
+			addStat(&stats_num_retry)
 			continue
 		}
 
-		if len(getDom) > 0 && !getDom[0] {
-			time.Sleep(delay) // Rate limit
-			stats_num_sleeps++
-			return body, nil, nil
-		}
+		limiter.Recover()
+		return body, nil
+	}
+	addStat(&stats_num_ratelimit_failures)
+	addStatLog(&stats_ratelimit_failures, url)
+	return nil, fmt.Errorf("failed to fetch page after %d retries", RetryLimit)
+}
 
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+// fetchPage fetches an HTML page or, with getDom false, any text such as the JSON endpoints.
+func fetchPage(url string, getDom ...bool) (string, *goquery.Document, error) {
+	bodyBytes, err := fetchBytes(url)
+	if err != nil {
+		return "", nil, err
+	}
+	body := string(bodyBytes)
+	if strings.Contains(body, "page not found") {
+		return "", nil, errPageNotFound
+	}
 
-		doc.Find("a.track-link").Each(func(index int, item *goquery.Selection) {
-			item.Remove()
-		})
+	if len(getDom) > 0 && !getDom[0] {
+		return body, nil, nil
+	}
 
-		if err != nil {
-			logFetchError(err, fmt.Sprintf("Error parsing HTML: %v, retrying...", url))
-			stats_num_retry++
-			time.Sleep(delayErr)
-			stats_num_sleeps++
-			continue
-		}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		logFetchError(err, fmt.Sprintf("Error parsing HTML: %v", url))
+		return "", nil, fmt.Errorf("failed to parse HTML of %s: %v", url, err)
+	}
+	doc.Find("a.track-link").Each(func(index int, item *goquery.Selection) {
+		item.Remove()
+	})
+	return body, doc, nil
+}
 
-		time.Sleep(delay) // Rate limit
-		stats_num_sleeps++
-		return body, doc, nil
+// throttle slows the shared limiter down after the server pushed back and pauses every
+// request for as long as its Retry-After header asks, or for fallback if there is none.
+// Returns the new request rate.
+func throttle(resp *http.Response, fallback time.Duration) float64 {
+	addStat(&stats_num_throttled)
+	limiter.SlowDown()
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		limiter.PauseFor(d)
+	} else {
+		limiter.PauseFor(fallback)
 	}
-	stats_num_ratelimit_failures++
-	stats_ratelimit_failures = append(stats_ratelimit_failures, url)
-	return "", nil, fmt.Errorf("failed to fetch page after %d retries", RetryLimit)
+	return limiter.Rate()
 }
 
 func logFetchError(err error, s ...string) {
@@ -279,9 +366,9 @@ func logFetchError(err error, s ...string) {
 
 	logged[0] = fmt.Sprintf("[FETCH_ERROR][%s][LINE: %d]: %v", file, line, err)
 
-	stats_error_log = append(stats_error_log, strings.Join(logged, "\n"))
+	addStatLog(&stats_error_log, strings.Join(logged, "\n"))
 
-	stats_num_errors++
+	addStat(&stats_num_errors)
 }
 
 //*func(): func getPageByNumRoutine():
@@ -290,21 +377,19 @@ func logFetchError(err error, s ...string) {
 // uses the above fetchPage function to get the page HTML and then uses the getLinksPerPage function to extract
 // the drug links from the page.
 /*
+ * @param filter: the listing filter the page belongs to
  * @param pageNum: the page number to scrape
- * @param wg: the WaitGroup to signal when the goroutine is done
- * @param linksChan: the channel to send the []DrugLink slice to
+ * @param linksChan: the channel to send the PageLinks to
  ! @returns: void
 */
-func getPageByNumRoutine(pageNum int, wg *sync.WaitGroup, linksChan chan<- []DrugLink) error {
-	defer wg.Done()
-
-	url := fmt.Sprintf(BASE_URL+"%d", pageNum)
+func getPageByNumRoutine(filter ListingFilter, pageNum int, linksChan chan<- PageLinks) error {
+	url := filter.URL(pageNum)
 	_, page, err := fetchPage(url)
 	if err != nil {
 		log.Printf("🔥 Error getting page: %v\n", err)
 		return err
 	}
-	linksChan <- getLinksPerPage(page)
+	linksChan <- PageLinks{Page: pageNum, Links: getLinksPerPage(page)}
 	return nil
 }
 
@@ -356,6 +441,14 @@ func htmlRawToFieldName(htmlRaw string) string {
 		return "Molecule"
 	case "CasNumber":
 		return "CAS"
+	case "ChemicalFormula":
+		return "Formula"
+	case "AtcCodes":
+		return "ATCCodes"
+	case "International/commercialBrands":
+		return "InternationalBrands"
+	case "Unapproved/otherProducts":
+		return "UnapprovedProducts"
 	}
 
 	return htmlRaw
@@ -419,30 +512,37 @@ func handleDrugInteractions(sibling *goquery.Selection, fieldPtr interface{}, na
 		return fmt.Errorf("handleDrugInteractions(): invalid arguments")
 	}
 
-	// Get the drug ID
-	id := json.ID
-	link := fmt.Sprintf(DRUG_INTERACTIONS_URL, id, 0, 100, time.Now().Unix())
-	page, _, err := fetchPage(link)
-
-	if err != nil {
-		return fmt.Errorf("handleDrugInteractions(): failed to fetch page: %v", err)
-	}
-
 	entries := sibling.Find("#drug-interactions-table_info").Text()
 
-	// Parse the JSON
-	interactions, err := ParseDrugInteractions(page)
+	id := json.ID
+	var interactions []Interaction
+	var warnings []string
+	read, total, err := pageDataTable(func(start int) string {
+		return fmt.Sprintf(DRUG_INTERACTIONS_URL, id, start, InteractionsPageSize, time.Now().Unix())
+	}, func(page string) (int, int, error) {
+		rows, rowWarnings, recordsTotal, err := ParseDrugInteractions(page)
+		if err != nil {
+			return 0, 0, err
+		}
+		if json.DrugInteractionsPage == nil {
+			json.DrugInteractionsPage = append(make([]string, 1), entries, page)
+		}
+		interactions = append(interactions, rows...)
+		warnings = append(warnings, rowWarnings...)
+		return len(rows) + len(rowWarnings), recordsTotal, nil
+	})
 
+	// Assign the interactions to the DrugInfo, also the ones read before a failed page
+	json.DrugInteractions = interactions
+	json.InteractionWarnings = warnings
+	complete := err == nil && read >= total
+	json.DrugInteractionsComplete = &complete
 	if err != nil {
-		return fmt.Errorf("handleDrugInteractions(): failed to parse JSON: %v", err)
+		return fmt.Errorf("handleDrugInteractions(): %v", err)
 	}
-
-	// Assign the interactions to the DrugInfo
-	json.DrugInteractions = interactions
-	json.DrugInteractionsPage = append(make([]string, 1), entries, page)
+	json.DrugInteractionsTotal = total
 
 	return nil
-
 }
 
 func handleListAsArray(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
@@ -462,7 +562,7 @@ func handleListAsArray(sibling *goquery.Selection, fieldPtr interface{}, name st
 	}
 
 	sibling.Find("li").Each(func(_ int, li *goquery.Selection) {
-		fieldsVal := strings.TrimSpace(li.Text())
+		fieldsVal := normalizeFieldValue(name, li.Text())
 		*slicePtr = append(*slicePtr, fieldsVal)
 	})
 	return nil
@@ -532,65 +632,162 @@ func handleInChIHashAndID(sibling *goquery.Selection, fieldPtr interface{}, name
 		return fmt.Errorf("handleInChIHashAndID: invalid arguments")
 	}
 
-	content := sibling.Text()
-
 	if name == "InchiKey" {
+		content := normalizeFieldValue("InChIKey", sibling.Text())
 		json.InChI.Hash = content
+		json.InChIKey = content
 	} else if name == "Inchi" {
-		json.InChI.ID = content
+		json.InChI.ID = normalizeFieldValue("InChI", sibling.Text())
 	} else {
 		return fmt.Errorf("handleInChIHashAndID: invalid name")
 	}
 	return nil
 }
 
+// Matches "Average: 258.358 Monoisotopic: 258.183109" with optional units after each mass
+var molecularWeightRegexp = regexp.MustCompile(`(?i)(average|monoisotopic)\s*:?\s*(` + NUMBER_PATTERN + `)\s*(kda|da|g/mol)?`)
+
 func handleMolecularWeight(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
 	json, ok := fieldPtr.(*DrugInfo)
 	if !ok {
-		return fmt.Errorf("handleMechanismOfAction: type assertion to *DrugInfo failed")
+		return fmt.Errorf("handleMolecularWeight: type assertion to *DrugInfo failed")
 	}
 
 	if sibling == nil || json == nil {
-		return fmt.Errorf("handleMechanismOfAction: invalid arguments")
+		return fmt.Errorf("handleMolecularWeight: invalid arguments")
 	}
 
-	content := normalize(sibling.Text())
-	parts := strings.Split(content, " ")
-
-	if len(parts) == 4 {
-		average, _ := strconv.ParseFloat(parts[1], 64)
-		monoisotopic, _ := strconv.ParseFloat(parts[3], 64)
+	weights, err := parseMolecularWeight(sibling.Text())
+	if err != nil {
+		return fmt.Errorf("handleMolecularWeight: %v", err)
+	}
 
-		json.Weight = append(json.Weight, MolWeight{
-			Type:   "average",
-			Weight: average,
-			Units:  "Da",
-		})
-		json.Weight = append(json.Weight, MolWeight{
-			Type:   "monoisotopic",
-			Weight: monoisotopic,
-			Units:  "Da",
-		})
-	} else {
-		// handle error
-		log.Printf("🔥 Error parsing molecular weight: %s\n", content)
+	json.Weights = weights
+	json.Weight = json.Weight[:0]
+	for _, weight := range []MolWeight{weights.Average, weights.Monoisotopic} {
+		if weight.Type != "" {
+			json.Weight = append(json.Weight, weight)
+		}
 	}
 	return nil
 }
 
+// parseMolecularWeight extracts the average and monoisotopic masses and their units from the
+// Weight field. Masses without units are in Da, the unit DrugBank uses for small molecules.
+func parseMolecularWeight(content string) (*Weights, error) {
+	weights := &Weights{}
+
+	for _, m := range molecularWeightRegexp.FindAllStringSubmatch(content, -1) {
+		value, err := parseDecimal(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid mass %q in %q", m[2], content)
+		}
+
+		units := "Da"
+		switch strings.ToLower(m[3]) {
+		case "kda":
+			units = "kDa"
+		case "g/mol":
+			units = "g/mol"
+		}
+
+		weight := MolWeight{Type: strings.ToLower(m[1]), Weight: value, Units: units}
+		if weight.Type == "average" {
+			weights.Average = weight
+		} else {
+			weights.Monoisotopic = weight
+		}
+	}
+
+	if weights.Average.Type == "" && weights.Monoisotopic.Type == "" {
+		return nil, fmt.Errorf("no average or monoisotopic mass in %q", normalize(content))
+	}
+	return weights, nil
+}
+
+// validateWeights cross-checks the scraped average mass against the one computed from the
+// scraped formula, once both are known.
+func validateWeights(drugInfo *DrugInfo) {
+	if drugInfo.Weights == nil || drugInfo.Weights.Average.Type == "" || drugInfo.Formula == "" {
+		return
+	}
+
+	average, known := massToDa(drugInfo.Weights.Average)
+	if !known {
+		return
+	}
+	computed, err := formulaMass(drugInfo.Formula)
+	if err != nil {
+		log.Printf("🔥 %s: %v\n", drugInfo.ID, err)
+		return
+	}
+
+	drugInfo.Weights.FormulaMass = math.Round(computed*1000) / 1000
+	drugInfo.Weights.FormulaMismatch = !weightMatchesFormula(average, computed)
+	if drugInfo.Weights.FormulaMismatch {
+		log.Printf("🔥 %s: average mass %.3f Da doesn't match formula %s (%.3f Da)\n", drugInfo.ID, average, drugInfo.Formula, computed)
+	}
+}
+
 var fieldHandlers = map[string]fieldHandler{
-	"Synonyms":          handleListAsArray,
-	"Categories":        handleListAsArray,
-	"Groups":            handleListAsArray,
-	"Description":       handleDescription,
-	"Weight":            handleMolecularWeight,
-	"Inchi":             handleInChIHashAndID,
-	"InchiKey":          handleInChIHashAndID,
-	"DrugInteractions":  handleDrugInteractions,
-	"MechanismOfAction": handleMechanismOfAction,
+	"Synonyms":               handleListAsArray,
+	"Categories":             handleListAsArray,
+	"DrugCategories":         handleDrugCategories,
+	"ATCCodes":               handleATCCodes,
+	"Unii":                   handleCrossReferences,
+	"ExternalIdentifiers":    handleCrossReferences,
+	"ExternalLinks":          handleCrossReferences,
+	"ExperimentalProperties": handleChemicalProperties,
+	"PredictedProperties":    handleChemicalProperties,
+	// ClassyFire chemical taxonomy, its Description is renamed in scrapePageRoutine
+	"TaxonomyDescription": handleChemicalTaxonomy,
+	"Kingdom":             handleChemicalTaxonomy,
+	"SuperClass":          handleChemicalTaxonomy,
+	"Class":               handleChemicalTaxonomy,
+	"SubClass":            handleChemicalTaxonomy,
+	"DirectParent":        handleChemicalTaxonomy,
+	"AlternativeParents":  handleChemicalTaxonomy,
+	"Substituents":        handleChemicalTaxonomy,
+	"MolecularFramework":  handleChemicalTaxonomy,
+	"ExternalDescriptors": handleChemicalTaxonomy,
+	"Groups":              handleListAsArray,
+	"Description":         handleDescription,
+	"Weight":              handleMolecularWeight,
+	"Inchi":               handleInChIHashAndID,
+	"InchiKey":            handleInChIHashAndID,
+	"DrugInteractions":    handleDrugInteractions,
+	"FoodInteractions":    handleFoodInteractions,
+	"ClinicalTrials":      handleClinicalTrials,
+	"GeneralReferences":   handleReferences,
+	"References":          handleReferences,
+	"MechanismOfAction":   handleMechanismOfAction,
+	"Packagers":           handleListAsArray,
+	"Manufacturers":       handleListAsArray,
+	"DosageForms":         handleDosageForms,
+	"Prices":              handlePrices,
+	// products tables, handleProducts tells them apart by name
+	"BrandNamePrescriptionProducts": handleProducts,
+	"GenericPrescriptionProducts":   handleProducts,
+	"OverTheCounterProducts":        handleProducts,
+	"MixtureProducts":               handleProducts,
+	"UnapprovedProducts":            handleProducts,
+	"InternationalBrands":           handleInternationalBrands,
 	// Add other handlers here...
 }
 
+// pageHandlers parse sections of the drug page that aren't a single dt/dd pair, they run
+// after all fieldHandlers so they can rely on the ID and other fields being set
+type pageHandler func(*goquery.Document, *DrugInfo) error
+
+var pageHandlers = []pageHandler{
+	handleBioInteractors,
+	// before handlePharmacokinetics so it parses the text without markers
+	handleReferenceMarkers,
+	handlePharmacokinetics,
+	handleStructureAssets,
+	// Add other page handlers here...
+}
+
 func setFieldByName(obj interface{}, fieldName string, newValue interface{}) error {
 	// Get the reflect.Value of obj, which must be a pointer to a struct
 	val := reflect.ValueOf(obj)
@@ -656,20 +853,8 @@ func assignField(drugInfo *DrugInfo, fieldString string, value string) {
 		return
 	}
 
-	var err error
-
-	switch fieldString {
-	case drugInfo.Description:
-		err = setFieldByName(drugInfo, fieldString, ToTitleCase(value))
-	case drugInfo.InChI.Hash:
-		err = setFieldByName(drugInfo, fieldString, strings.ToUpper(value))
-	case drugInfo.ID:
-		err = setFieldByName(drugInfo, fieldString, strings.ToUpper(value))
-	case drugInfo.IupacName:
-		err = setFieldByName(drugInfo, fieldString, value)
-	default:
-		err = setFieldByName(drugInfo, fieldString, value)
-	}
+	// identifiers keep their exact source text, see fieldValuePolicies
+	err := setFieldByName(drugInfo, fieldString, normalizeFieldValue(fieldString, value))
 
 	if err != nil {
 		log.Printf("🔥 Error assigning field '%s': %v", fieldString, err)
@@ -722,8 +907,7 @@ func saveToFile(data interface{}, subdir string, filename string) {
 }
 
 // New function for better error handling in goroutines
-func scrapePageRoutine(pageLink DrugLink, wg *sync.WaitGroup, drugInfosChan chan<- DrugInfo) {
-	defer wg.Done()
+func scrapePageRoutine(pageLink DrugLink, drugInfosChan chan<- DrugInfo) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Println("Recovered in scrapePageRoutine:", r)
@@ -731,7 +915,10 @@ func scrapePageRoutine(pageLink DrugLink, wg *sync.WaitGroup, drugInfosChan chan
 	}()
 
 	_, page, err := fetchPage(pageLink.Link)
-	if err != nil {
+	if errors.Is(err, errPageNotFound) {
+		log.Printf("ℹ️ No drug page for %s\n", pageLink.Name)
+		return
+	} else if err != nil {
 		log.Printf("Error getting page: %v\n", err)
 		return
 	}
@@ -758,6 +945,15 @@ func scrapePageRoutine(pageLink DrugLink, wg *sync.WaitGroup, drugInfosChan chan
 
 	// fmt print stub notice with emoji
 	page.Find("dl").Find("dt").Each(func(_ int, s *goquery.Selection) {
+		// the dl of target, enzyme, carrier and transporter cards belong to handleBioInteractors
+		if s.Closest(".bond").Length() > 0 {
+			return
+		}
+		// so do the dl nested in a dd, like the sources listed under External Identifiers
+		if s.ParentsFiltered("dd").Length() > 0 {
+			return
+		}
+
 		title := normalize(s.Text())
 		sibling := s.Next()
 
@@ -773,6 +969,9 @@ func scrapePageRoutine(pageLink DrugLink, wg *sync.WaitGroup, drugInfosChan chan
 		}
 
 		propertyName := getFieldInfo(&json, title)
+		if propertyName == "Description" && isTaxonomyBlock(s) {
+			propertyName = "TaxonomyDescription"
+		}
 
 		if propertyName != "" {
 			if handler, exists := fieldHandlers[propertyName]; exists {
@@ -799,6 +998,13 @@ func scrapePageRoutine(pageLink DrugLink, wg *sync.WaitGroup, drugInfosChan chan
 			}
 		}
 	})
+	for _, handler := range pageHandlers {
+		if err := handler(page, &json); err != nil {
+			log.Printf("Error handling page of %s with '%s': %v", json.ID, runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), err)
+		}
+	}
+
+	validateWeights(&json)
 	drugInfosChan <- json
 }
 
@@ -852,49 +1058,52 @@ type DrugInfoStats struct {
 	NumRequests           int                 `json:"numRequests"`
 	NumErrors             int                 `json:"numErrors"`
 	NumSleeps             int                 `json:"numSleeps"`
+	NumThrottled          int                 `json:"numThrottled"`
+	WeightMismatches      []string            `json:"weightMismatches,omitempty"`
+	ChemicalClasses       map[string]int      `json:"chemicalClasses,omitempty"`
 	ErrorLog              []string            `json:"errorLog"`
 }
 
-// Define a struct to match the JSON structure
-type DrugInteraction struct {
-	Draw            int
-	RecordsTotal    int `json:"recordsTotal"`
-	RecordsFiltered int `json:"recordsFiltered"`
-	Data            [][]string
-}
-
 // Function to parse the JSON and extract the data from the drug interactions JSON endpoint
-// the url is of the form: {BASE_URL}/drugs/{id}/drug_interactions.json
+// the url is of the form: https://go.drugbank.com/drugs/{id}/drug_interactions.json
 //        w/ query params: ?start={p_start}&length={num_rows_return}&_={cache_timestamp}
 
-// Function to parse the drug interactions
-func ParseDrugInteractions(jsonData string) ([][]string, error) {
-	var di DrugInteraction
+// Function to parse one page of the drug interactions, also returns the recordsTotal of the whole table.
+// Rows that don't parse are left out and described in the returned warnings.
+func ParseDrugInteractions(jsonData string) ([]Interaction, []string, int, error) {
+	var di DataTablesPage
 
 	// Unmarshal the JSON data
 	err := json.Unmarshal([]byte(jsonData), &di)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 
-	var interactions [][]string
+	var interactions []Interaction
+	var warnings []string
 	for _, data := range di.Data {
-		// Regular expression to match the <a> tags and extract the necessary parts
-		re := regexp.MustCompile(`<a href="/drugs/([^"]+)">([^<]+)</a>`)
-		matches := re.FindStringSubmatch(data[0])
-
-		var resultArray []string
-		if len(matches) >= 3 {
-			drugID := matches[1]
-			drugName := matches[2]
+		if len(data) < 2 {
+			warnings = append(warnings, fmt.Sprintf("interaction row has %d columns, expected 2: %q", len(data), data))
+			continue
+		}
 
-			resultArray = append(resultArray, drugID, drugName, data[1])
+		// Match the <a> tag and extract the partner ID and name
+		matches := interactionLinkRegexp.FindStringSubmatch(data[0])
+		if len(matches) < 3 {
+			warnings = append(warnings, fmt.Sprintf("no drug link in interaction row: %q", data[0]))
+			continue
 		}
 
-		interactions = append(interactions, resultArray)
+		interaction := Interaction{
+			PartnerID:   matches[1],
+			PartnerName: matches[2],
+			Description: strings.TrimSpace(data[1]),
+		}
+		interaction.Effect, interaction.Property = parseInteractionEffect(interaction.Description)
+		interactions = append(interactions, interaction)
 	}
 
-	return interactions, nil
+	return interactions, warnings, di.RecordsTotal, nil
 }
 
 // Helper function to check the validity of a DrugInfo object
@@ -935,180 +1144,214 @@ func isValidDrugInfo(drugInfo DrugInfo) bool {
 	return true
 }
 
-func main() {
-	// get user input for page number
-	var count, id int
-
-	args := os.Args[1:]
+// collectPageLinks fetches the given listing pages of filter on the pool and returns every drug link found on them.
+// Pages already in the journal or in fetched are not fetched again, new ones are journaled as they complete.
+func collectPageLinks(pool *WorkerPool, journal *Journal, filter ListingFilter, pages []int, fetched map[int][]DrugLink) []DrugLink {
 	links := make([]DrugLink, 0)
-
-	linksChan := make(chan []DrugLink)
+	linksChan := make(chan PageLinks)
 	var wg_buildLinksSlice sync.WaitGroup
 
-	if len(args) >= 1 {
-		mode := args[0]
-		switch mode {
-		case "ID":
-			id = getIntFromUserInput(fmt.Sprintf("Enter DB ID (max. %v)", MAX_PAGE))
-			if id >= 0 && id <= 99999 {
-				fmtId := fmt.Sprintf("DB0%v", id)
-				thisLink := DrugLink{
-					Name: fmtId,
-					Link: fmt.Sprintf(DRUG_BASE_URL, fmtId),
-				}
-				links = append(links, thisLink)
-			} else {
-				panic(fmt.Sprintf("❌ Max ID value is 5 digits, i.e %v\n", 99999))
+	todo := make([]int, 0, len(pages))
+	skipped := 0
+	for _, pageNum := range pages {
+		if pageLinks, done := journal.CompletedPage(pageNum); done {
+			links = append(links, pageLinks...)
+			skipped++
+			continue
+		}
+		if pageLinks, ok := fetched[pageNum]; ok {
+			links = append(links, pageLinks...)
+			if err := journal.PageDone(pageNum, pageLinks); err != nil {
+				log.Printf("🔥 Error writing journal: %v\n", err)
 			}
-		case "numPages":
-			count = getIntFromUserInput(fmt.Sprintf("Enter number of pages to scrape (max. %v)", MAX_PAGE))
-
+			continue
 		}
+		todo = append(todo, pageNum)
+	}
+	if skipped > 0 {
+		fmt.Printf("ℹ️ skipping %v listing pages completed by a previous run\n", skipped)
 	}
 
-	if count != 0 {
-
-		// check if count is within range
-		if count > MAX_PAGE {
-			fmt.Printf("❌ Max page is %v\n", MAX_PAGE)
-			return
-		}
-
-		for i := 0; i < count; i++ {
+	go func() {
+		for _, pageNum := range todo {
 			wg_buildLinksSlice.Add(1)
-			pageNum := i // Capture the current value of i
-			go func(pageNum int) {
-				err := getPageByNumRoutine(pageNum, &wg_buildLinksSlice, linksChan)
+			pageNum := pageNum // Capture the current value of pageNum
+			pool.Submit(filter.URL(pageNum), func() {
+				defer wg_buildLinksSlice.Done()
+				err := getPageByNumRoutine(filter, pageNum, linksChan)
 				if err != nil {
 					log.Printf("🔥 Error getting page: %v\n", err)
 				}
-			}(pageNum)
+			})
 		}
+		wg_buildLinksSlice.Wait()
+		close(linksChan)
+	}()
 
-		go func() {
-			wg_buildLinksSlice.Wait()
-			close(linksChan)
-		}()
-
-		for pageLinks := range linksChan {
-			fmt.Printf("ℹ️ collected %v total links... \n", len(links))
-			links = append(links, pageLinks...)
+	for pageLinks := range linksChan {
+		fmt.Printf("ℹ️ collected %v total links... \n", len(links))
+		links = append(links, pageLinks.Links...)
+		if err := journal.PageDone(pageLinks.Page, pageLinks.Links); err != nil {
+			log.Printf("🔥 Error writing journal: %v\n", err)
 		}
-
-	} else if id != 0 {
-		fmt.Printf("Links: %v", links)
 	}
+	return links
+}
 
+// scrapeDrugLinks queues every drug page in links that isn't in the journal yet on the pool
+// and streams the results in completion order. The returned channel is closed once all pages are done.
+func scrapeDrugLinks(pool *WorkerPool, journal *Journal, links []DrugLink) <-chan DrugInfo {
 	var wg_buildDrugInfoSlice sync.WaitGroup
-	drugInfosChan := make(chan DrugInfo, len(links)) // Adjusted the buffer size
+	drugInfosChan := make(chan DrugInfo)
 
-	for _, link := range links {
-		wg_buildDrugInfoSlice.Add(1)
-		go scrapePageRoutine(link, &wg_buildDrugInfoSlice, drugInfosChan)
-	}
+	go func() {
+		for _, link := range links {
+			if journal.CompletedDrug(link.Link) {
+				continue
+			}
+			wg_buildDrugInfoSlice.Add(1)
+			link := link
+			pool.Submit(link.Link, func() {
+				defer wg_buildDrugInfoSlice.Done()
+				scrapePageRoutine(link, drugInfosChan)
+			})
+		}
+		wg_buildDrugInfoSlice.Wait()
+		close(drugInfosChan)
+	}()
 
-	wg_buildDrugInfoSlice.Wait()
-	close(drugInfosChan)
+	return drugInfosChan
+}
 
-	// Collect all data into a slice
-	var drugInfos []DrugInfo
+// statsCollector builds the field, duplicate and validity statistics one drug at a time,
+// so stats can be computed while streaming results instead of over one big slice.
+type statsCollector struct {
+	drugInfoStats DrugInfoStats
+	fieldLengths  map[string]int
+}
 
-	drugInfoStats := DrugInfoStats{
-		Stats: Stats{
-			FieldCounts:         NewUniqueSet[string, int](),
-			AverageFieldLengths: NewUniqueSet[string, int](),
-			FieldCompleteness:   NewUniqueSet[string, string](),
-			TotalStubs:          0,
-			TotalLength:         0,
-		},
-		DuplicateEntries: DuplicateEntries{
-			IDSet: NewUniqueSet[string, bool](),
-			Total: 0,
-		},
-		ValidDrugInfos: ValidDrugInfos{
-			IDSet: NewUniqueSet[string, bool](),
-			Total: 0,
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		drugInfoStats: DrugInfoStats{
+			Stats: Stats{
+				FieldCounts:         NewUniqueSet[string, int](),
+				AverageFieldLengths: NewUniqueSet[string, int](),
+				FieldCompleteness:   NewUniqueSet[string, string](),
+				TotalStubs:          0,
+				TotalLength:         0,
+			},
+			DuplicateEntries: DuplicateEntries{
+				IDSet: NewUniqueSet[string, bool](),
+				Total: 0,
+			},
+			ValidDrugInfos: ValidDrugInfos{
+				IDSet: NewUniqueSet[string, bool](),
+				Total: 0,
+			},
+			ValidDrugInfosLengths: NewUniqueSet[int, int](),
+			ChemicalClasses:       make(map[string]int),
 		},
-		ValidDrugInfosLengths: NewUniqueSet[int, int](),
+		fieldLengths: make(map[string]int),
 	}
+}
 
-	fieldLengths := make(map[string]int)
+func (c *statsCollector) Add(drugInfo DrugInfo) {
+	drugInfoStats := &c.drugInfoStats
 
-	for drugInfo := range drugInfosChan {
+	// Update TotalLength
+	drugInfoStats.Stats.TotalLength++
 
-		// Update TotalLength
-		drugInfoStats.Stats.TotalLength++
+	// Check if it's a stub
+	if drugInfo.IsStub {
+		drugInfoStats.Stats.TotalStubs++
+	}
 
-		// Check if it's a stub
-		if drugInfo.IsStub {
-			drugInfoStats.Stats.TotalStubs++
+	// Check for duplicates
+	id := drugInfo.ID // Assuming ID is the unique identifier
+	if _, exists := drugInfoStats.DuplicateEntries.IDSet.Get(id); !exists {
+		drugInfoStats.DuplicateEntries.IDSet.Add(id, false)
+	} else {
+		if duplicate, _ := drugInfoStats.DuplicateEntries.IDSet.Get(id); !duplicate {
+			drugInfoStats.DuplicateEntries.IDSet.Add(id, true) // Mark as duplicate
+			drugInfoStats.DuplicateEntries.Total++
 		}
+	}
 
-		// Check for duplicates
-		id := drugInfo.ID // Assuming ID is the unique identifier
-		if _, exists := drugInfoStats.DuplicateEntries.IDSet.Get(id); !exists {
-			drugInfoStats.DuplicateEntries.IDSet.Add(id, false)
-		} else {
-			if duplicate, _ := drugInfoStats.DuplicateEntries.IDSet.Get(id); !duplicate {
-				drugInfoStats.DuplicateEntries.IDSet.Add(id, true) // Mark as duplicate
-				drugInfoStats.DuplicateEntries.Total++
-			}
-		}
+	// Check the scraped mass against the formula
+	if drugInfo.Weights != nil && drugInfo.Weights.FormulaMismatch {
+		drugInfoStats.WeightMismatches = append(drugInfoStats.WeightMismatches, fmt.Sprintf("%s: %s is %.3f Da, scraped %.3f %s", id, drugInfo.Formula, drugInfo.Weights.FormulaMass, drugInfo.Weights.Average.Weight, drugInfo.Weights.Average.Units))
+	}
 
-		// Check for valid DrugInfo
-		isValid := isValidDrugInfo(drugInfo) // Implement this function based on your validity criteria
-		if isValid {
-			drugInfoStats.ValidDrugInfos.IDSet.Add(id, true)
-			drugInfoStats.ValidDrugInfos.Total++
-		}
+	// Count drugs per ClassyFire class
+	if drugInfo.Taxonomy != nil && drugInfo.Taxonomy.Class != "" {
+		drugInfoStats.ChemicalClasses[drugInfo.Taxonomy.Class]++
+	}
 
-		val := reflect.ValueOf(drugInfo)
-		thisLength := reflect.TypeOf(drugInfo).NumField()
-		fmt.Printf("Reflected kind: %v\n", val.Kind()) // Debugging line
+	// Check for valid DrugInfo
+	isValid := isValidDrugInfo(drugInfo) // Implement this function based on your validity criteria
+	if isValid {
+		drugInfoStats.ValidDrugInfos.IDSet.Add(id, true)
+		drugInfoStats.ValidDrugInfos.Total++
+	}
 
-		if val.Kind() == reflect.Ptr && !val.IsNil() {
-			val = val.Elem()
-			fmt.Printf("Dereferenced kind: %v\n", val.Kind()) // Debugging line
-		}
-		for i := 0; i < thisLength; i++ {
-			fieldName := reflect.TypeOf(drugInfo).Field(i).Name
-			fieldValue := val.Field(i)
+	val := reflect.ValueOf(drugInfo)
+	thisLength := reflect.TypeOf(drugInfo).NumField()
 
-			// Update FieldCounts
-			currentCount, _ := drugInfoStats.Stats.FieldCounts.Get(fieldName)
-			drugInfoStats.Stats.FieldCounts.Add(fieldName, currentCount+1)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	for i := 0; i < thisLength; i++ {
+		fieldName := reflect.TypeOf(drugInfo).Field(i).Name
+		fieldValue := val.Field(i)
 
-			// Update field lengths for string fields
-			if strVal, ok := fieldValue.Interface().(string); ok {
-				fieldLengths[fieldName] += len(strVal)
-			}
-		}
+		// Update FieldCounts
+		currentCount, _ := drugInfoStats.Stats.FieldCounts.Get(fieldName)
+		drugInfoStats.Stats.FieldCounts.Add(fieldName, currentCount+1)
 
-		// Append the drugInfo to the slice
-		drugInfos = append(drugInfos, drugInfo)
+		// Update field lengths for string fields
+		if strVal, ok := fieldValue.Interface().(string); ok {
+			c.fieldLengths[fieldName] += len(strVal)
+		}
 	}
+}
+
+// Finish calculates the averages and completeness and adds the request counters of the current process.
+func (c *statsCollector) Finish() DrugInfoStats {
+	drugInfoStats := c.drugInfoStats
 
-	// Calculate averages and completeness after the loop
 	for field, count := range drugInfoStats.Stats.FieldCounts {
 		avgLength := 0
 		if count > 0 {
-			avgLength = fieldLengths[field] / count
+			avgLength = c.fieldLengths[field] / count
 		}
 		drugInfoStats.Stats.AverageFieldLengths.Add(field, avgLength)
-		completeness := fmt.Sprintf("%.2f%%", float64(count)/float64(len(drugInfos))*100)
+		completeness := fmt.Sprintf("%.2f%%", float64(count)/float64(drugInfoStats.Stats.TotalLength)*100)
 		drugInfoStats.Stats.FieldCompleteness.Add(field, completeness)
 	}
 
+	stats_mu.Lock()
+	defer stats_mu.Unlock()
 	drugInfoStats.NumErrors = stats_num_errors
 	drugInfoStats.NumRequests = stats_num_requests
 	drugInfoStats.NumRetries = stats_num_retry
 	drugInfoStats.NumSleeps = stats_num_sleeps
+	drugInfoStats.NumThrottled = stats_num_throttled
 	drugInfoStats.ErrorLog = stats_error_log
 
-	PrettyPrint(drugInfos)
-	PrettyPrint(drugInfoStats)
+	return drugInfoStats
+}
+
+// computeDrugInfoStats builds the stats for a set of results held in memory.
+func computeDrugInfoStats(drugInfos []DrugInfo) DrugInfoStats {
+	collector := newStatsCollector()
+	for _, drugInfo := range drugInfos {
+		collector.Add(drugInfo)
+	}
+	return collector.Finish()
+}
 
-	// save debug data to file and also save the results to a file
-	saveToFile(drugInfoStats, "logs", "drugInfoStats.json")
-	saveToFile(drugInfos, "results", fmt.Sprintf("%d_len%d", time.Now().Unix(), len(drugInfos)))
+func main() {
+	if err := runCLI(os.Args[1:]); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 }
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestParseMolecularWeight(t *testing.T) {
+	tests := []struct {
+		content          string
+		wantAverage      MolWeight
+		wantMonoisotopic MolWeight
+	}{
+		{"Average: 180.159 Monoisotopic: 180.042258736", MolWeight{"average", 180.159, "Da"}, MolWeight{"monoisotopic", 180.042258736, "Da"}},
+		{"Average: 1,234.56 Monoisotopic: 1,233.9", MolWeight{"average", 1234.56, "Da"}, MolWeight{"monoisotopic", 1233.9, "Da"}},
+		{"Average: 6963,425", MolWeight{"average", 6963.425, "Da"}, MolWeight{}},
+		{"Average: 6,963 Da", MolWeight{"average", 6963, "Da"}, MolWeight{}},
+		{"Average: 148.5 kDa", MolWeight{"average", 148.5, "kDa"}, MolWeight{}},
+		{"Monoisotopic: 46.0418648 g/mol", MolWeight{}, MolWeight{"monoisotopic", 46.0418648, "g/mol"}},
+	}
+
+	for _, tt := range tests {
+		weights, err := parseMolecularWeight(tt.content)
+		if err != nil {
+			t.Errorf("parseMolecularWeight(%q): %v", tt.content, err)
+			continue
+		}
+		if weights.Average != tt.wantAverage || weights.Monoisotopic != tt.wantMonoisotopic {
+			t.Errorf("parseMolecularWeight(%q) = %+v, %+v, want %+v, %+v", tt.content, weights.Average, weights.Monoisotopic, tt.wantAverage, tt.wantMonoisotopic)
+		}
+	}
+
+	if _, err := parseMolecularWeight("Not Available"); err == nil {
+		t.Error("parseMolecularWeight() accepted text without masses")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunMigrateCommandSkipsRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	runDir := filepath.Join(dir, "run_1700000000")
+	if err := os.Mkdir(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(dir, "results.json"):         `[{"id": "DB00001", "smiles": "cc(=o)o"}, {"id": "DB00002", "smiles": "CC(=O)O"}]`,
+		filepath.Join(dir, LISTING_CACHE_FILE):     `{"https://go.drugbank.com/drugs?page=1": {"pages": 664, "checked": 1700000000}}`,
+		filepath.Join(runDir, "drugs-0001.ndjson"): `{"id": "DB00003", "formula": "c9h8o4"}` + "\n",
+		filepath.Join(runDir, JOURNAL_FILE):        `{"id": "DB00004", "status": "done"}` + "\n",
+		filepath.Join(runDir, MANIFEST_FILE):       `{"started": 1700000000}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	if err := runMigrateCommand([]string{"--report", reportPath, dir, runDir}); err != nil {
+		t.Fatalf("runMigrateCommand(): %v", err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reports []CasingReport
+	if err := json.Unmarshal(data, &reports); err != nil {
+		t.Fatal(err)
+	}
+
+	drugs := make(map[string]int)
+	for _, report := range reports {
+		if report.Error != "" {
+			t.Errorf("%s: %s", report.File, report.Error)
+		}
+		drugs[filepath.Base(report.File)] += report.Drugs
+	}
+	want := map[string]int{"results.json": 2, "drugs-0001.ndjson": 1}
+	if len(drugs) != len(want) || drugs["results.json"] != 2 || drugs["drugs-0001.ndjson"] != 1 {
+		t.Errorf("checked %v, want %v", drugs, want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NUMBER_PATTERN matches a number written with a decimal point or comma, "0.5" or "0,5", or
+// with thousands separators, "1,200" or "1,234.56". A comma followed by exactly three digits is
+// read as a thousands separator.
+const NUMBER_PATTERN = `[0-9]+,[0-9]{4,}|[0-9]{1,3}(?:,[0-9]{3})+(?:\.[0-9]+)?|[0-9]+(?:[.,][0-9]+)?`
+
+var thousandsSeparatorRegexp = regexp.MustCompile(`^-?[0-9]{1,3}(?:,[0-9]{3})+(?:\.[0-9]+)?$`)
+
+// parseDecimal parses a number matched by NUMBER_PATTERN.
+func parseDecimal(text string) (float64, error) {
+	if thousandsSeparatorRegexp.MatchString(text) {
+		text = strings.ReplaceAll(text, ",", "")
+	} else {
+		text = strings.Replace(text, ",", ".", 1)
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// ValuePolicy decides how a scraped value is cleaned up before it is stored.
+type ValuePolicy int
+
+const (
+	// PolicyText collapses runs of whitespace, the default for free text fields
+	PolicyText ValuePolicy = iota
+	// PolicyExact keeps the source text as is apart from surrounding whitespace. Chemical
+	// identifiers are case sensitive: lowercase SMILES atoms are aromatic, InChIKeys are uppercase
+	PolicyExact
+	// PolicyUpper uppercases accession numbers such as DB00001
+	PolicyUpper
+)
+
+// fieldValuePolicies maps DrugInfo field names to their policy, anything missing is PolicyText.
+var fieldValuePolicies = map[string]ValuePolicy{
+	"ID":        PolicyUpper,
+	"CAS":       PolicyExact,
+	"Smiles":    PolicyExact,
+	"InChI":     PolicyExact,
+	"InChIKey":  PolicyExact,
+	"Formula":   PolicyExact,
+	"IupacName": PolicyExact,
+}
+
+var whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+// normalizeFieldValue applies the policy of the given DrugInfo field to value.
+func normalizeFieldValue(field string, value string) string {
+	value = strings.TrimSpace(value)
+
+	switch fieldValuePolicies[field] {
+	case PolicyExact:
+		return value
+	case PolicyUpper:
+		return strings.ToUpper(value)
+	}
+	return whitespaceRegexp.ReplaceAllString(value, " ")
+}
+
+// CasingReport lists the drugs of one results file whose identifiers were stored lowercased
+// by older versions of the scraper and need to be scraped again.
+type CasingReport struct {
+	File          string         `json:"file"`
+	Drugs         int            `json:"drugs"`
+	AffectedIDs   []string       `json:"affected_ids,omitempty"`
+	AffectedField map[string]int `json:"affected_fields,omitempty"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// lowercasedIdentifiers returns the identifier fields of a raw results record that lost their casing.
+// Records are read as plain maps since historical files don't all match the current DrugInfo.
+func lowercasedIdentifiers(record map[string]any) []string {
+	var fields []string
+	hasUpper := func(s string) bool { return strings.ToLower(s) != s }
+	hasLetter := func(s string) bool { return strings.ToUpper(s) != s || hasUpper(s) }
+
+	if id, _ := record["id"].(string); id != "" && !hasUpper(id) {
+		fields = append(fields, "id")
+	}
+	// fully aromatic SMILES such as benzene's c1ccccc1 are legitimately lowercase, but rare enough
+	if smiles, _ := record["smiles"].(string); smiles != "" && hasLetter(smiles) && !hasUpper(smiles) {
+		fields = append(fields, "smiles")
+	}
+	if formula, _ := record["formula"].(string); formula != "" && !hasUpper(formula) {
+		fields = append(fields, "formula")
+	}
+	if key, _ := record["inchi_key"].(string); key != "" && key != strings.ToUpper(key) {
+		fields = append(fields, "inchi_key")
+	}
+
+	// inchi used to be {"key", "id"}, now {"hash", "id"}
+	if inchi, ok := record["inchi"].(map[string]any); ok {
+		if id, _ := inchi["id"].(string); id != "" && !strings.EqualFold(id, "not available") && !strings.HasPrefix(id, "InChI=") {
+			fields = append(fields, "inchi.id")
+		}
+		for _, name := range []string{"key", "hash"} {
+			if key, _ := inchi[name].(string); key != "" && key != strings.ToUpper(key) {
+				fields = append(fields, "inchi."+name)
+			}
+		}
+	}
+	return fields
+}
+
+// auditCasing checks one historical results file for lowercased identifiers.
+func auditCasing(path string) CasingReport {
+	report := CasingReport{File: path, AffectedField: make(map[string]int)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	var records []map[string]any
+	if strings.HasSuffix(path, ".ndjson") {
+		for _, line := range strings.Split(string(data), "\n") {
+			var record map[string]any
+			if strings.TrimSpace(line) != "" && json.Unmarshal([]byte(line), &record) == nil {
+				records = append(records, record)
+			}
+		}
+	} else if err := json.Unmarshal(data, &records); err != nil {
+		report.Error = fmt.Sprintf("not a results file: %v", err)
+		return report
+	}
+
+	report.Drugs = len(records)
+	for _, record := range records {
+		fields := lowercasedIdentifiers(record)
+		if len(fields) == 0 {
+			continue
+		}
+		id, _ := record["id"].(string)
+		report.AffectedIDs = append(report.AffectedIDs, strings.ToUpper(id))
+		for _, field := range fields {
+			report.AffectedField[field]++
+		}
+	}
+	sort.Strings(report.AffectedIDs)
+	return report
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"0.5", 0.5},
+		{"0,5", 0.5},
+		{"12,34", 12.34},
+		{"1,200", 1200},
+		{"1,000", 1000},
+		{"1,234.56", 1234.56},
+		{"12,345,678", 12345678},
+		{"1,2345", 1.2345},
+		{"-1,200", -1200},
+		{"42", 42},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.text)
+		if err != nil {
+			t.Errorf("parseDecimal(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDecimal(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeFieldValue(t *testing.T) {
+	tests := []struct {
+		field, value, want string
+	}{
+		{"ID", " db00001 ", "DB00001"},
+		{"Smiles", " CC(=O)Oc1ccccc1C(O)=O\n", "CC(=O)Oc1ccccc1C(O)=O"},
+		{"InChIKey", "BSYNRYMUTXBXSQ-UHFFFAOYSA-N", "BSYNRYMUTXBXSQ-UHFFFAOYSA-N"},
+		{"Formula", "C9H8O4", "C9H8O4"},
+		{"Groups", "  Approved,\n  Vet approved ", "Approved, Vet approved"},
+		{"Type", "Small Molecule", "Small Molecule"},
+		{"Description", "Aspirin  is\tan NSAID", "Aspirin is an NSAID"},
+	}
+	for _, tt := range tests {
+		if got := normalizeFieldValue(tt.field, tt.value); got != tt.want {
+			t.Errorf("normalizeFieldValue(%q, %q) = %q, want %q", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
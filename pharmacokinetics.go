@@ -0,0 +1,145 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PKValue is the first value or range with recognised units found in a pharmacokinetic text,
+// e.g. "4-6 hours" is {Min: 4, Max: 6, Units: "hours"}. A single value has Min == Max, a
+// bound has only one end: "< 1 hour" is {Max: 1, Bound: "upper"}, "> 90%" is {Min: 90, Bound: "lower"}.
+// Normalized holds the same range in the parameter's common units for sorting across drugs.
+type PKValue struct {
+	Raw             string   `json:"raw"`
+	Min             *float64 `json:"min,omitempty"`
+	Max             *float64 `json:"max,omitempty"`
+	Units           string   `json:"units,omitempty"`
+	Approximate     bool     `json:"approximate,omitempty"`
+	Bound           string   `json:"bound,omitempty"` // "upper" or "lower"
+	NormalizedMin   *float64 `json:"normalized_min,omitempty"`
+	NormalizedMax   *float64 `json:"normalized_max,omitempty"`
+	NormalizedUnits string   `json:"normalized_units,omitempty"`
+}
+
+// Pharmacokinetics holds the parsed values of the pharmacokinetic free text fields of DrugInfo.
+type Pharmacokinetics struct {
+	HalfLife             *PKValue `json:"half_life,omitempty"`
+	Clearance            *PKValue `json:"clearance,omitempty"`
+	Absorption           *PKValue `json:"absorption,omitempty"` // bioavailability in %
+	VolumeOfDistribution *PKValue `json:"volume_of_distribution,omitempty"`
+	ProteinBinding       *PKValue `json:"protein_binding,omitempty"`
+}
+
+// pkUnit converts a unit as written on DrugBank to the normalized units of its parameter.
+type pkUnit struct {
+	Normalized string
+	Factor     float64
+}
+
+var (
+	pkTimeUnits = map[string]pkUnit{
+		"seconds": {"h", 1.0 / 3600}, "second": {"h", 1.0 / 3600}, "sec": {"h", 1.0 / 3600}, "s": {"h", 1.0 / 3600},
+		"minutes": {"h", 1.0 / 60}, "minute": {"h", 1.0 / 60}, "min": {"h", 1.0 / 60}, "mins": {"h", 1.0 / 60},
+		"hours": {"h", 1}, "hour": {"h", 1}, "hrs": {"h", 1}, "hr": {"h", 1}, "h": {"h", 1},
+		"days": {"h", 24}, "day": {"h", 24}, "d": {"h", 24},
+		"weeks": {"h", 168}, "week": {"h", 168},
+	}
+	pkVolumeUnits = map[string]pkUnit{
+		"l/kg": {"L/kg", 1}, "ml/kg": {"L/kg", 0.001},
+		"l": {"L", 1}, "ml": {"L", 0.001},
+		"l/m2": {"L/m2", 1}, "l/m²": {"L/m2", 1},
+	}
+	pkClearanceUnits = map[string]pkUnit{
+		"ml/min": {"mL/min", 1}, "l/h": {"mL/min", 1000.0 / 60}, "l/hr": {"mL/min", 1000.0 / 60}, "ml/h": {"mL/min", 1.0 / 60}, "l/min": {"mL/min", 1000},
+		"ml/min/kg": {"mL/min/kg", 1}, "l/h/kg": {"mL/min/kg", 1000.0 / 60}, "l/hr/kg": {"mL/min/kg", 1000.0 / 60}, "ml/h/kg": {"mL/min/kg", 1.0 / 60},
+		"ml/min/1.73m2": {"mL/min/1.73m2", 1}, "ml/min/1.73 m2": {"mL/min/1.73m2", 1}, "ml/min/1.73m²": {"mL/min/1.73m2", 1},
+	}
+	pkPercentUnits = map[string]pkUnit{
+		"%": {"%", 1}, "percent": {"%", 1},
+	}
+
+	// "approximately 4 to 6 hours", "~99%", "0.1-0.2 L/kg", "< 1 hour", "1,200 mL/min"
+	pkValueRegexp = regexp.MustCompile(`(?i)(?:(~|≈|approximately|approx\.?|about|around|roughly|nearly)|(<|≤|less than|up to|>|≥|greater than|more than|over))?\s*(` + NUMBER_PATTERN + `)\s*(?:%?\s*(?:-|–|to|and)\s*(` + NUMBER_PATTERN + `)\s*)?(mL/min/1\.73 ?m[2²]|[a-zA-Z%²/.]+(?:/[a-zA-Z0-9.²]+)*)`)
+	// the spread of "39 ± 5 mL/min/kg", dropped so the mean is read
+	pkSpreadRegexp = regexp.MustCompile(`\s*(?:±|\+/-)\s*(?:` + NUMBER_PATTERN + `)`)
+)
+
+// pkBounds are the qualifiers of pkValueRegexp that make a value one end of an open range.
+var pkBounds = map[string]string{
+	"<": "upper", "≤": "upper", "less than": "upper", "up to": "upper",
+	">": "lower", "≥": "lower", "greater than": "lower", "more than": "lower", "over": "lower",
+}
+
+// parsePKValue finds the first value or range in text written in one of units.
+func parsePKValue(text string, units map[string]pkUnit) *PKValue {
+	if text == "" {
+		return nil
+	}
+
+	for _, m := range pkValueRegexp.FindAllStringSubmatch(pkSpreadRegexp.ReplaceAllString(text, ""), -1) {
+		unitText := strings.TrimRight(m[5], ".,;")
+		unit, known := units[strings.ToLower(unitText)]
+		if !known {
+			continue
+		}
+
+		value := &PKValue{Raw: text, Units: unitText, Approximate: m[1] != ""}
+		value.Min = parsePropertyNumber(m[3])
+		value.Max = value.Min
+		if m[4] != "" {
+			value.Max = parsePropertyNumber(m[4])
+		}
+		if value.Min == nil || value.Max == nil {
+			continue
+		}
+		if bound := pkBounds[strings.ToLower(m[2])]; bound != "" && m[4] == "" {
+			value.Bound = bound
+			if bound == "upper" {
+				value.Min = nil
+			} else {
+				value.Max = nil
+			}
+		}
+
+		value.NormalizedMin, value.NormalizedMax = scalePK(value.Min, unit.Factor), scalePK(value.Max, unit.Factor)
+		value.NormalizedUnits = unit.Normalized
+		return value
+	}
+	return &PKValue{Raw: text}
+}
+
+func scalePK(value *float64, factor float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	scaled := *value * factor
+	return &scaled
+}
+
+// handlePharmacokinetics parses the pharmacokinetic text fields once assignField has set them.
+func handlePharmacokinetics(page *goquery.Document, json *DrugInfo) error {
+	pk := Pharmacokinetics{
+		HalfLife:             parsePKValue(json.HalfLife, pkTimeUnits),
+		Clearance:            parsePKValue(json.Clearance, pkClearanceUnits),
+		Absorption:           parsePKValue(json.Absorption, pkPercentUnits),
+		VolumeOfDistribution: parsePKValue(json.VolumeOfDistribution, pkVolumeUnits),
+		ProteinBinding:       parsePKValue(json.ProteinBinding, pkPercentUnits),
+	}
+	if pk != (Pharmacokinetics{}) {
+		json.Pharmacokinetics = &pk
+	}
+	return nil
+}
+
+// pkParameters lists the parsed values by the column names used in the sinks.
+func (pk *Pharmacokinetics) pkParameters() map[string]*PKValue {
+	return map[string]*PKValue{
+		"half_life":              pk.HalfLife,
+		"clearance":              pk.Clearance,
+		"absorption":             pk.Absorption,
+		"volume_of_distribution": pk.VolumeOfDistribution,
+		"protein_binding":        pk.ProteinBinding,
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestParsePKValue(t *testing.T) {
+	tests := []struct {
+		text            string
+		units           map[string]pkUnit
+		wantMin         *float64
+		wantMax         *float64
+		wantUnits       string
+		wantApproximate bool
+		wantBound       string
+		wantNormMin     *float64
+		wantNormMax     *float64
+	}{
+		{"The half-life is 4-6 hours.", pkTimeUnits, float(4), float(6), "hours", false, "", float(4), float(6)},
+		{"approximately 30 minutes", pkTimeUnits, float(30), float(30), "minutes", true, "", float(0.5), float(0.5)},
+		{"2 to 3 days", pkTimeUnits, float(2), float(3), "days", false, "", float(48), float(72)},
+		{"< 1 hour", pkTimeUnits, nil, float(1), "hour", false, "upper", nil, float(1)},
+		{"Clearance is 1,200 mL/min.", pkClearanceUnits, float(1200), float(1200), "mL/min", false, "", float(1200), float(1200)},
+		{"39 ± 5 mL/min/kg", pkClearanceUnits, float(39), float(39), "mL/min/kg", false, "", float(39), float(39)},
+		{"1,000 L", pkVolumeUnits, float(1000), float(1000), "L", false, "", float(1000), float(1000)},
+		{"0,5 L/kg", pkVolumeUnits, float(0.5), float(0.5), "L/kg", false, "", float(0.5), float(0.5)},
+		{"~99%", pkPercentUnits, float(99), float(99), "%", true, "", float(99), float(99)},
+		{"> 90% bound to albumin", pkPercentUnits, float(90), nil, "%", false, "lower", float(90), nil},
+		{"95-98%", pkPercentUnits, float(95), float(98), "%", false, "", float(95), float(98)},
+	}
+
+	for _, tt := range tests {
+		got := parsePKValue(tt.text, tt.units)
+		if got == nil {
+			t.Errorf("parsePKValue(%q) = nil", tt.text)
+			continue
+		}
+		if !sameFloat(got.Min, tt.wantMin) || !sameFloat(got.Max, tt.wantMax) || got.Units != tt.wantUnits || got.Approximate != tt.wantApproximate || got.Bound != tt.wantBound {
+			t.Errorf("parsePKValue(%q) = %v..%v %q approximate=%v bound=%q, want %v..%v %q approximate=%v bound=%q", tt.text,
+				deref(got.Min), deref(got.Max), got.Units, got.Approximate, got.Bound,
+				deref(tt.wantMin), deref(tt.wantMax), tt.wantUnits, tt.wantApproximate, tt.wantBound)
+		}
+		if !sameFloat(got.NormalizedMin, tt.wantNormMin) || !sameFloat(got.NormalizedMax, tt.wantNormMax) {
+			t.Errorf("parsePKValue(%q) normalized = %v..%v, want %v..%v", tt.text, deref(got.NormalizedMin), deref(got.NormalizedMax), deref(tt.wantNormMin), deref(tt.wantNormMax))
+		}
+	}
+
+	if got := parsePKValue("Not known", pkTimeUnits); got == nil || got.Min != nil || got.Raw != "Not known" {
+		t.Errorf("parsePKValue() without a value = %+v, want only the raw text", got)
+	}
+	if got := parsePKValue("", pkTimeUnits); got != nil {
+		t.Errorf("parsePKValue(\"\") = %+v, want nil", got)
+	}
+}
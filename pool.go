@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/url"
+	"sync"
+)
+
+const (
+	DefaultWorkers = 8
+	DefaultPerHost = 4
+)
+
+// WorkerPool runs fetch jobs on a fixed number of workers fed from a bounded queue.
+// Submit blocks once the queue is full, so producers can never run ahead of the
+// workers, and no more than perHost jobs hit the same host at the same time.
+type WorkerPool struct {
+	jobs    chan poolJob
+	workers sync.WaitGroup
+
+	perHost int
+	mu      sync.Mutex
+	hosts   map[string]chan struct{}
+}
+
+type poolJob struct {
+	host string
+	run  func()
+}
+
+func NewWorkerPool(workers, perHost, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if perHost < 1 || perHost > workers {
+		perHost = workers
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &WorkerPool{
+		jobs:    make(chan poolJob, queueSize),
+		perHost: perHost,
+		hosts:   make(map[string]chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+func (p *WorkerPool) work() {
+	defer p.workers.Done()
+	for job := range p.jobs {
+		sem := p.hostSemaphore(job.host)
+		sem <- struct{}{}
+		job.run()
+		<-sem
+	}
+}
+
+func (p *WorkerPool) hostSemaphore(host string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sem, exists := p.hosts[host]
+	if !exists {
+		sem = make(chan struct{}, p.perHost)
+		p.hosts[host] = sem
+	}
+	return sem
+}
+
+// Submit queues run as a fetch of rawURL, blocking while the queue is full.
+func (p *WorkerPool) Submit(rawURL string, run func()) {
+	host := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	p.jobs <- poolJob{host: host, run: run}
+}
+
+// Close stops accepting jobs and waits for the queued ones to finish.
+func (p *WorkerPool) Close() {
+	close(p.jobs)
+	p.workers.Wait()
+}
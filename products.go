@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// productKinds maps the field names of the product tables to the Product kind they list.
+var productKinds = map[string]string{
+	"BrandNamePrescriptionProducts": "brand",
+	"GenericPrescriptionProducts":   "generic",
+	"OverTheCounterProducts":        "otc",
+	"MixtureProducts":               "mixture",
+	"UnapprovedProducts":            "unapproved",
+}
+
+// Product is one row of the marketed products tables. Mixture and unapproved products
+// list their active ingredients, the other tables are single ingredient products.
+type Product struct {
+	Kind           string   `json:"kind"`
+	Name           string   `json:"name"`
+	Ingredients    []string `json:"ingredients,omitempty"`
+	DosageForm     string   `json:"dosage_form,omitempty"`
+	Strength       string   `json:"strength,omitempty"`
+	Route          string   `json:"route,omitempty"`
+	Labeller       string   `json:"labeller,omitempty"`
+	MarketingStart string   `json:"marketing_start,omitempty"` // approval date, YYYY-MM-DD
+	MarketingEnd   string   `json:"marketing_end,omitempty"`
+	Country        string   `json:"country,omitempty"` // region column, e.g. "US", "Canada", "EU"
+}
+
+// Brand is one of the international/commercial brand names a drug is sold under.
+type Brand struct {
+	Name    string `json:"name"`
+	Company string `json:"company,omitempty"`
+}
+
+type DosageForm struct {
+	Form     string `json:"form"`
+	Route    string `json:"route,omitempty"`
+	Strength string `json:"strength,omitempty"`
+}
+
+// Price is one row of the prices table, Cost is left at 0 when the cost column isn't a number.
+type Price struct {
+	Description string  `json:"description"`
+	Cost        float64 `json:"cost,omitempty"`
+	Currency    string  `json:"currency,omitempty"`
+	Unit        string  `json:"unit,omitempty"`
+	Raw         string  `json:"raw,omitempty"` // cost column as shown on the page
+}
+
+// Matches "$0.53", "USD 12.00" or "1,234.5"
+var priceRegexp = regexp.MustCompile(`^\s*(\$|USD|CAD|EUR|€)?\s*([0-9][0-9,]*(?:\.[0-9]+)?)`)
+
+// tableRows maps every body row of the table in sibling by its normalized column headers.
+func tableRows(sibling *goquery.Selection) []map[string]string {
+	var headers []string
+	sibling.Find("thead th").Each(func(_ int, th *goquery.Selection) {
+		headers = append(headers, normalize(normalizeFieldValue("", th.Text())))
+	})
+
+	var rows []map[string]string
+	sibling.Find("tbody tr").Each(func(_ int, tr *goquery.Selection) {
+		row := make(map[string]string)
+		tr.Find("td").Each(func(i int, td *goquery.Selection) {
+			if i < len(headers) {
+				value := normalizeFieldValue("", td.Text())
+				if !strings.EqualFold(value, "not available") {
+					row[headers[i]] = value
+				}
+			}
+		})
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	})
+	return rows
+}
+
+// firstOf returns the first non empty column of row out of several possible header names.
+func firstOf(row map[string]string, headers ...string) string {
+	for _, header := range headers {
+		if value := row[header]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func handleProducts(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleProducts(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleProducts(): invalid arguments")
+	}
+
+	kind, known := productKinds[name]
+	if !known {
+		return fmt.Errorf("handleProducts(): unknown products table '%s'", name)
+	}
+
+	for _, row := range tableRows(sibling) {
+		product := Product{
+			Kind:           kind,
+			Name:           row["name"],
+			DosageForm:     firstOf(row, "dosage", "dosage form", "form"),
+			Strength:       row["strength"],
+			Route:          row["route"],
+			Labeller:       firstOf(row, "labeller", "labeler", "company"),
+			MarketingStart: row["marketing start"],
+			MarketingEnd:   row["marketing end"],
+			Country:        firstOf(row, "region", "country"),
+		}
+		if ingredients := firstOf(row, "ingredients", "ingredient"); ingredients != "" {
+			for _, ingredient := range strings.Split(ingredients, "+") {
+				product.Ingredients = append(product.Ingredients, strings.TrimSpace(ingredient))
+			}
+		}
+		if product.Name != "" {
+			json.Products = append(json.Products, product)
+		}
+	}
+	return nil
+}
+
+func handleInternationalBrands(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleInternationalBrands(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleInternationalBrands(): invalid arguments")
+	}
+
+	for _, row := range tableRows(sibling) {
+		brand := Brand{Name: firstOf(row, "name", "brand name", "brand"), Company: firstOf(row, "company", "labeller")}
+		if brand.Name != "" {
+			json.InternationalBrands = append(json.InternationalBrands, brand)
+		}
+	}
+	return nil
+}
+
+func handleDosageForms(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleDosageForms(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleDosageForms(): invalid arguments")
+	}
+
+	for _, row := range tableRows(sibling) {
+		form := DosageForm{Form: firstOf(row, "form", "dosage form"), Route: row["route"], Strength: row["strength"]}
+		if form.Form != "" {
+			json.DosageForms = append(json.DosageForms, form)
+		}
+	}
+	return nil
+}
+
+func handlePrices(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handlePrices(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handlePrices(): invalid arguments")
+	}
+
+	for _, row := range tableRows(sibling) {
+		price := Price{
+			Description: firstOf(row, "unit description", "description"),
+			Unit:        row["unit"],
+			Raw:         row["cost"],
+		}
+		price.Cost, price.Currency = parsePrice(price.Raw)
+		if price.Description != "" {
+			json.Prices = append(json.Prices, price)
+		}
+	}
+	return nil
+}
+
+// parsePrice reads the cost and currency out of a cost cell, DrugBank lists US prices in dollars.
+func parsePrice(raw string) (float64, string) {
+	m := priceRegexp.FindStringSubmatch(raw)
+	if m == nil {
+		return 0, ""
+	}
+	cost, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", ""), 64)
+	if err != nil {
+		return 0, ""
+	}
+
+	switch m[1] {
+	case "CAD":
+		return cost, "CAD"
+	case "EUR", "€":
+		return cost, "EUR"
+	}
+	return cost, "USD"
+}
+
+// brandNames lists the distinct names a drug is marketed under, from both its products and
+// its international brands.
+func brandNames(drugInfo DrugInfo) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	for _, product := range drugInfo.Products {
+		add(product.Name)
+	}
+	for _, brand := range drugInfo.InternationalBrands {
+		add(brand.Name)
+	}
+	return names
+}
+
+// dosageFormNames lists the distinct dosage forms of a drug, e.g. "Tablet, film coated".
+func dosageFormNames(drugInfo DrugInfo) []string {
+	seen := make(map[string]bool)
+	var forms []string
+	for _, form := range drugInfo.DosageForms {
+		if !seen[form.Form] {
+			seen[form.Form] = true
+			forms = append(forms, form.Form)
+		}
+	}
+	return forms
+}
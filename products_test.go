@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTableRows(t *testing.T) {
+	page := fixture(t, `<table>
+		<thead><tr><th>Name</th><th> Dosage  Form </th><th>Strength</th></tr></thead>
+		<tbody>
+			<tr><td>Aspirin</td><td>Tablet,
+				film coated</td><td>81 mg/1</td><td>extra cell</td></tr>
+			<tr><td>Not Available</td><td>Not Available</td><td>not available</td></tr>
+			<tr><td>Bayer</td><td>Tablet</td></tr>
+		</tbody></table>`)
+
+	rows := tableRows(page.Selection)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %v", len(rows), rows)
+	}
+	if rows[0]["name"] != "Aspirin" || rows[0]["dosage form"] != "Tablet, film coated" || rows[0]["strength"] != "81 mg/1" {
+		t.Errorf("first row = %v", rows[0])
+	}
+	if _, exists := rows[1]["strength"]; exists || rows[1]["name"] != "Bayer" {
+		t.Errorf("second row = %v", rows[1])
+	}
+}
+
+func TestHandleProducts(t *testing.T) {
+	page := fixture(t, `<table>
+		<thead><tr><th>Name</th><th>Ingredients</th><th>Dosage</th><th>Route</th><th>Labeller</th><th>Marketing Start</th><th>Marketing End</th><th>Region</th></tr></thead>
+		<tbody>
+			<tr><td>Aggrenox</td><td>Acetylsalicylic acid (25 mg) + Dipyridamole (200 mg)</td><td>Capsule, extended release</td><td>Oral</td><td>Boehringer Ingelheim</td><td>1999-11-22</td><td>Not Available</td><td>US</td></tr>
+			<tr><td></td><td>Acetylsalicylic acid</td><td>Tablet</td><td>Oral</td><td>Nobody</td><td></td><td></td><td>US</td></tr>
+		</tbody></table>`)
+
+	var drugInfo DrugInfo
+	if err := handleProducts(page.Selection, &drugInfo, "MixtureProducts"); err != nil {
+		t.Fatal(err)
+	}
+	if len(drugInfo.Products) != 1 {
+		t.Fatalf("got %d products, want 1: %+v", len(drugInfo.Products), drugInfo.Products)
+	}
+	got := drugInfo.Products[0]
+	want := Product{
+		Kind: "mixture", Name: "Aggrenox", DosageForm: "Capsule, extended release", Route: "Oral", Labeller: "Boehringer Ingelheim",
+		MarketingStart: "1999-11-22", Country: "US",
+	}
+	if !slices.Equal(got.Ingredients, []string{"Acetylsalicylic acid (25 mg)", "Dipyridamole (200 mg)"}) {
+		t.Errorf("ingredients = %q", got.Ingredients)
+	}
+	got.Ingredients = nil
+	if got.Kind != want.Kind || got.Name != want.Name || got.DosageForm != want.DosageForm || got.Route != want.Route ||
+		got.Labeller != want.Labeller || got.MarketingStart != want.MarketingStart || got.MarketingEnd != "" || got.Country != want.Country {
+		t.Errorf("product = %+v, want %+v", got, want)
+	}
+
+	if err := handleProducts(page.Selection, &drugInfo, "Prices"); err == nil {
+		t.Error("handleProducts() accepted an unknown products table")
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		raw          string
+		wantCost     float64
+		wantCurrency string
+	}{
+		{"$0.53", 0.53, "USD"},
+		{"USD 12.00", 12, "USD"},
+		{"1,234.5", 1234.5, "USD"},
+		{"CAD 3.10", 3.1, "CAD"},
+		{"€ 7", 7, "EUR"},
+		{"EUR 0.99 per tablet", 0.99, "EUR"},
+		{"Not Available", 0, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		cost, currency := parsePrice(tt.raw)
+		if cost != tt.wantCost || currency != tt.wantCurrency {
+			t.Errorf("parsePrice(%q) = %v, %q, want %v, %q", tt.raw, cost, currency, tt.wantCost, tt.wantCurrency)
+		}
+	}
+}
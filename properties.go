@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ChemicalProperty is one row of the Experimental or Predicted Properties tables. Value keeps
+// the text shown on the page, Numeric and Units are parsed from it when it is a number or a
+// range (then NumericMax is the upper bound), Boolean is set for yes/no rows like Rule of Five.
+type ChemicalProperty struct {
+	Kind       string   `json:"kind"` // "experimental" or "predicted"
+	Name       string   `json:"name"` // e.g. "logp", "pka_strongest_acidic", "melting_point"
+	Label      string   `json:"label"`
+	Value      string   `json:"value"`
+	Numeric    *float64 `json:"numeric,omitempty"`
+	NumericMax *float64 `json:"numeric_max,omitempty"`
+	Units      string   `json:"units,omitempty"`
+	Boolean    *bool    `json:"boolean,omitempty"`
+	Source     string   `json:"source,omitempty"` // e.g. "ALOGPS", "Chemaxon" or a reference
+}
+
+var (
+	// "4.6 mg/mL", "135-136", "-0.8", "1.2e-3 g/L (at 25 °C)", "> 300 °C"
+	propertyValueRegexp = regexp.MustCompile(`^\s*[<>~≈]?=?\s*(-?(?:` + NUMBER_PATTERN + `)(?:[eE][-+]?[0-9]+)?)(?:\s*(?:-|–|to)\s*(-?(?:` + NUMBER_PATTERN + `)))?\s*([^()]*?)\s*(?:\(.*\))?\s*$`)
+	// units given with the label, "melting point (°C)"
+	propertyLabelUnitsRegexp = regexp.MustCompile(`\s*\(([^)]*)\)\s*$`)
+	propertyNameRegexp       = regexp.MustCompile(`[^a-z0-9]+`)
+	propertyAcronymRegexp    = regexp.MustCompile(`^[A-Z]{2,}$`)
+)
+
+// propertyLabelUnits are the units a label can give in parentheses without a °, / or %,
+// anything else in parentheses qualifies the name, as in "pKa (Strongest Acidic)" or
+// "Polar Surface Area (PSA)".
+var propertyLabelUnits = map[string]bool{
+	"k": true, "da": true, "kda": true, "å": true, "å²": true, "å2": true, "a²": true,
+	"ppm": true, "nm": true, "mmhg": true, "atm": true, "pa": true, "kpa": true, "cp": true,
+}
+
+// parsePropertyNumber reads a number matched by NUMBER_PATTERN, nil if it isn't one.
+func parsePropertyNumber(s string) *float64 {
+	value, err := parseDecimal(s)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// newChemicalProperty parses one table row into a ChemicalProperty.
+func newChemicalProperty(kind string, label string, value string, source string) ChemicalProperty {
+	property := ChemicalProperty{Kind: kind, Label: label, Value: value, Source: source}
+
+	name := label
+	labelUnits := ""
+	if m := propertyLabelUnitsRegexp.FindStringSubmatch(label); m != nil {
+		// "pKa (Strongest Acidic)" qualifies the name, "melting point (°C)" gives units and
+		// "Polar Surface Area (PSA)" just abbreviates it
+		if strings.ContainsAny(m[1], "°/%") || propertyLabelUnits[strings.ToLower(m[1])] {
+			labelUnits = m[1]
+			name = strings.TrimSuffix(label, m[0])
+		} else if propertyAcronymRegexp.MatchString(m[1]) {
+			name = strings.TrimSuffix(label, m[0])
+		}
+	}
+	property.Name = strings.Trim(propertyNameRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+
+	switch strings.ToLower(value) {
+	case "yes", "true":
+		b := true
+		property.Boolean = &b
+		return property
+	case "no", "false":
+		b := false
+		property.Boolean = &b
+		return property
+	}
+
+	if m := propertyValueRegexp.FindStringSubmatch(value); m != nil {
+		property.Numeric = parsePropertyNumber(m[1])
+		if m[2] != "" {
+			property.NumericMax = parsePropertyNumber(m[2])
+		}
+		property.Units = strings.TrimSpace(m[3])
+	}
+	if property.Units == "" {
+		property.Units = labelUnits
+	}
+	return property
+}
+
+// handleChemicalProperties reads the Experimental and Predicted Properties tables.
+func handleChemicalProperties(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleChemicalProperties(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleChemicalProperties(): invalid arguments")
+	}
+
+	kind := "experimental"
+	properties := &json.ExperimentalProperties
+	if name == "PredictedProperties" {
+		kind = "predicted"
+		properties = &json.PredictedProperties
+	}
+
+	for _, row := range tableRows(sibling) {
+		if row["property"] == "" || row["value"] == "" {
+			continue
+		}
+		*properties = append(*properties, newChemicalProperty(kind, row["property"], row["value"], row["source"]))
+	}
+	return nil
+}
+
+// propertyValue returns the numeric value of a predicted property, or else the experimental one.
+func propertyValue(drugInfo DrugInfo, name string) (float64, bool) {
+	for _, properties := range [][]ChemicalProperty{drugInfo.PredictedProperties, drugInfo.ExperimentalProperties} {
+		for _, property := range properties {
+			if property.Name == name && property.Numeric != nil {
+				return *property.Numeric, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestNewChemicalProperty(t *testing.T) {
+	tests := []struct {
+		label     string
+		value     string
+		wantName  string
+		wantMin   *float64
+		wantMax   *float64
+		wantUnits string
+		wantBool  *bool
+	}{
+		{"logP", "-0.8", "logp", float(-0.8), nil, "", nil},
+		{"Water Solubility", "4.6 mg/mL", "water_solubility", float(4.6), nil, "mg/mL", nil},
+		{"Water Solubility", "1,200 mg/L", "water_solubility", float(1200), nil, "mg/L", nil},
+		{"Water Solubility", "1.2e-3 g/L (at 25 °C)", "water_solubility", float(0.0012), nil, "g/L", nil},
+		{"melting point (°C)", "135-136", "melting_point", float(135), float(136), "°C", nil},
+		{"logS", "-2,5", "logs", float(-2.5), nil, "", nil},
+		{"Polar Surface Area (PSA)", "79.9 Å²", "polar_surface_area", float(79.9), nil, "Å²", nil},
+		{"Polar Surface Area (PSA)", "79.9", "polar_surface_area", float(79.9), nil, "", nil},
+		{"pKa (Strongest Acidic)", "3.5", "pka_strongest_acidic", float(3.5), nil, "", nil},
+		{"boiling point (K)", "> 300", "boiling_point", float(300), nil, "K", nil},
+		{"Rule of Five", "Yes", "rule_of_five", nil, nil, "", boolean(true)},
+		{"Ghose Filter", "No", "ghose_filter", nil, nil, "", boolean(false)},
+	}
+
+	for _, tt := range tests {
+		got := newChemicalProperty("predicted", tt.label, tt.value, "ALOGPS")
+		if got.Name != tt.wantName || got.Units != tt.wantUnits || !sameFloat(got.Numeric, tt.wantMin) || !sameFloat(got.NumericMax, tt.wantMax) || !sameBool(got.Boolean, tt.wantBool) {
+			t.Errorf("newChemicalProperty(%q, %q) = %s %v..%v %q %v, want %s %v..%v %q %v", tt.label, tt.value,
+				got.Name, deref(got.Numeric), deref(got.NumericMax), got.Units, got.Boolean,
+				tt.wantName, deref(tt.wantMin), deref(tt.wantMax), tt.wantUnits, tt.wantBool)
+		}
+	}
+}
+
+func float(f float64) *float64 { return &f }
+
+func boolean(b bool) *bool { return &b }
+
+func deref(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func sameFloat(a, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameBool(a, b *bool) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// fixture parses an HTML snippet of a drug page for the handler tests.
+func fixture(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	page, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRequestsPerSecond = 1.0
+	DefaultBurst             = 2
+	// never slow down below one request every MinRateDivisor-th of the configured rate
+	MinRateDivisor = 16
+	// successful requests needed before the rate is raised again after a slow down
+	RecoverAfter = 20
+)
+
+// RateLimiter is a token bucket shared by every fetchBytes call, so the request rate
+// against DrugBank has a hard ceiling no matter how many workers are running.
+// It halves its rate whenever the server pushes back (429, a 403 Cloudflare challenge,
+// Cloudflare 1015) and creeps back up to the configured rate after a run of successful requests.
+type RateLimiter struct {
+	mu          sync.Mutex
+	baseRate    float64
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	successes   int
+}
+
+// limiter is the process-wide rate limiter used by fetchBytes, replaced by ScrapeConfig.apply.
+var limiter = NewRateLimiter(DefaultRequestsPerSecond, DefaultBurst)
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		baseRate: requestsPerSecond,
+		rate:     requestsPerSecond,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent.
+func (r *RateLimiter) Wait() {
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		r.refill(now)
+
+		var wait time.Duration
+		if now.Before(r.pausedUntil) {
+			wait = r.pausedUntil.Sub(now)
+		} else if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return
+		} else {
+			wait = time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		}
+		r.mu.Unlock()
+
+		time.Sleep(wait)
+	}
+}
+
+func (r *RateLimiter) refill(now time.Time) {
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+}
+
+// SlowDown halves the current rate and drops any saved up burst.
+func (r *RateLimiter) SlowDown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refill(time.Now())
+	r.rate /= 2
+	if minRate := r.baseRate / MinRateDivisor; r.rate < minRate {
+		r.rate = minRate
+	}
+	r.tokens = 0
+	r.successes = 0
+}
+
+// PauseFor stops all requests for d, e.g. as asked by a Retry-After header.
+func (r *RateLimiter) PauseFor(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if until := time.Now().Add(d); until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+}
+
+// Recover records a successful request, doubling the rate again (up to the configured
+// rate) after RecoverAfter of them in a row.
+func (r *RateLimiter) Recover() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rate >= r.baseRate {
+		return
+	}
+	r.successes++
+	if r.successes >= RecoverAfter {
+		r.rate *= 2
+		if r.rate > r.baseRate {
+			r.rate = r.baseRate
+		}
+		r.successes = 0
+	}
+}
+
+// Rate returns the current requests per second.
+func (r *RateLimiter) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rate
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSlowDownAndRecover(t *testing.T) {
+	r := NewRateLimiter(8, 4)
+
+	r.SlowDown()
+	if r.Rate() != 4 || r.tokens != 0 {
+		t.Errorf("after one slow down: rate %v, tokens %v, want 4, 0", r.Rate(), r.tokens)
+	}
+	for i := 0; i < 10; i++ {
+		r.SlowDown()
+	}
+	if want := 8.0 / MinRateDivisor; r.Rate() != want {
+		t.Errorf("after many slow downs: rate %v, want the floor %v", r.Rate(), want)
+	}
+
+	// one success short of recovering, then a slow down restarts the count
+	for i := 0; i < RecoverAfter-1; i++ {
+		r.Recover()
+	}
+	r.SlowDown()
+	for i := 0; i < RecoverAfter-1; i++ {
+		r.Recover()
+	}
+	if want := 8.0 / MinRateDivisor; r.Rate() != want {
+		t.Errorf("recovered before %d successes in a row: rate %v", RecoverAfter, r.Rate())
+	}
+	r.Recover()
+	if want := 2 * 8.0 / MinRateDivisor; r.Rate() != want {
+		t.Errorf("after %d successes: rate %v, want %v", RecoverAfter, r.Rate(), want)
+	}
+
+	for i := 0; i < 10*RecoverAfter; i++ {
+		r.Recover()
+	}
+	if r.Rate() != 8 {
+		t.Errorf("recovered past the configured rate: %v", r.Rate())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"120", 120 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-5", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.header)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(future); !ok || got < 80*time.Second || got > 90*time.Second {
+		t.Errorf("parseRetryAfter(%q) = %v, %v, want about 90s", future, got, ok)
+	}
+}
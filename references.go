@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ReferenceMarkers decides what happens to the inline [A1234] markers of the text fields, set
+// with --reference-markers. The cited keys are recorded in DrugInfo.Citations in every mode.
+var ReferenceMarkers = "strip"
+
+const (
+	// remove the markers from the text
+	MARKERS_STRIP = "strip"
+	// rewrite the markers to markdown links to the reference, [A1234](https://pubmed.ncbi.nlm.nih.gov/12345)
+	MARKERS_LINK = "link"
+	// leave the text as scraped
+	MARKERS_KEEP = "keep"
+)
+
+// Reference is one entry of a drug's bibliography, Key is the citation key used by the inline
+// markers. Its first letter tells the kind: A articles, L links, T textbooks, F attachments.
+type Reference struct {
+	Key      string `json:"key"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title,omitempty"`
+	PubMedID string `json:"pubmed_id,omitempty"`
+	DOI      string `json:"doi,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Citation string `json:"citation"`
+}
+
+var referenceKinds = map[byte]string{
+	'A': "article",
+	'L': "link",
+	'T': "textbook",
+	'F': "attachment",
+}
+
+var (
+	// "[A1234]", "[A1234, L567]"
+	referenceMarkerRegexp = regexp.MustCompile(`\s?\[((?:[ALTF][0-9]+)(?:\s*,\s*[ALTF][0-9]+)*)\]`)
+	referenceKeyRegexp    = regexp.MustCompile(`[ALTF][0-9]+`)
+	pubMedRegexp          = regexp.MustCompile(`(?:pubmed(?:\.ncbi\.nlm\.nih\.gov)?/|ncbi\.nlm\.nih\.gov/pubmed/)([0-9]+)`)
+	doiRegexp             = regexp.MustCompile(`\b10\.[0-9]{4,9}/[^\s"<>]+`)
+	// "Smith J, Doe A: Title of the article. J Pharm. 2002 Mar;12(3):45-9."
+	referenceTitleRegexp = regexp.MustCompile(`^[^:]{1,400}:\s+(.+?[.?!])(?:\s|$)`)
+)
+
+// handleReferences reads the numbered reference lists of the References section.
+func handleReferences(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleReferences(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleReferences(): invalid arguments")
+	}
+
+	sibling.Find("li").Each(func(_ int, li *goquery.Selection) {
+		reference, ok := parseReference(li)
+		if !ok {
+			return
+		}
+		for _, existing := range json.References {
+			if existing.Key == reference.Key {
+				return
+			}
+		}
+		json.References = append(json.References, reference)
+	})
+	return nil
+}
+
+// parseReference reads one li of a reference list, its key comes from the li id "reference-A1234".
+func parseReference(li *goquery.Selection) (Reference, bool) {
+	id, _ := li.Attr("id")
+	key := referenceKeyRegexp.FindString(strings.TrimPrefix(id, "reference-"))
+	if key == "" {
+		return Reference{}, false
+	}
+
+	reference := Reference{
+		Key:      key,
+		Kind:     referenceKinds[key[0]],
+		Citation: normalizeFieldValue("", li.Text()),
+	}
+	// the trailing [Article] / [Link] label isn't part of the citation
+	reference.Citation = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(reference.Citation, "[Article]"), "[Link]"))
+
+	li.Find("a").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		if m := pubMedRegexp.FindStringSubmatch(href); m != nil && reference.PubMedID == "" {
+			reference.PubMedID = m[1]
+		} else if reference.URL == "" && strings.HasPrefix(href, "http") {
+			reference.URL = href
+		}
+	})
+	if doi := doiRegexp.FindString(reference.Citation + " " + reference.URL); doi != "" {
+		reference.DOI = strings.TrimRight(doi, ".,;)]")
+	}
+
+	if m := referenceTitleRegexp.FindStringSubmatch(reference.Citation); m != nil && reference.Kind != "link" {
+		reference.Title = strings.TrimSuffix(m[1], ".")
+	} else if text := normalizeFieldValue("", li.Find("a").First().Text()); reference.Kind == "link" && text != "" {
+		reference.Title = text
+	}
+	return reference, true
+}
+
+// referenceURL is where a marker links to, PubMed first, then the DOI, then the reference's own link.
+func (reference Reference) referenceURL() string {
+	switch {
+	case reference.PubMedID != "":
+		return "https://pubmed.ncbi.nlm.nih.gov/" + reference.PubMedID
+	case reference.DOI != "":
+		return "https://doi.org/" + reference.DOI
+	}
+	return reference.URL
+}
+
+// handleReferenceMarkers records which references every text field cites, then strips or links
+// the markers according to ReferenceMarkers. It runs after handleReferences filled the bibliography.
+func handleReferenceMarkers(page *goquery.Document, json *DrugInfo) error {
+	urls := make(map[string]string, len(json.References))
+	for _, reference := range json.References {
+		urls[reference.Key] = reference.referenceURL()
+	}
+
+	for field, text := range ExtractFieldsOfType[string](json) {
+		// identifiers such as SMILES use brackets of their own
+		if fieldValuePolicies[field] == PolicyExact || !referenceMarkerRegexp.MatchString(*text) {
+			continue
+		}
+
+		var keys []string
+		for _, m := range referenceMarkerRegexp.FindAllStringSubmatch(*text, -1) {
+			keys = append(keys, referenceKeyRegexp.FindAllString(m[1], -1)...)
+		}
+		if json.Citations == nil {
+			json.Citations = make(map[string][]string)
+		}
+		json.Citations[jsonFieldName(field)] = uniqueSorted(keys)
+
+		switch ReferenceMarkers {
+		case MARKERS_STRIP:
+			*text = referenceMarkerRegexp.ReplaceAllString(*text, "")
+		case MARKERS_LINK:
+			*text = referenceMarkerRegexp.ReplaceAllStringFunc(*text, func(marker string) string {
+				var links []string
+				for _, key := range referenceKeyRegexp.FindAllString(marker, -1) {
+					if url := urls[key]; url != "" {
+						links = append(links, fmt.Sprintf("[%s](%s)", key, url))
+					} else {
+						links = append(links, "["+key+"]")
+					}
+				}
+				return " " + strings.Join(links, ", ")
+			})
+		}
+	}
+	return nil
+}
+
+// pubMedIDs lists the PubMed IDs of a drug's bibliography.
+func pubMedIDs(drugInfo DrugInfo) []string {
+	var ids []string
+	for _, reference := range drugInfo.References {
+		if reference.PubMedID != "" {
+			ids = append(ids, reference.PubMedID)
+		}
+	}
+	return ids
+}
+
+// jsonFieldName returns the name a DrugInfo field is written under in the results.
+func jsonFieldName(field string) string {
+	if f, ok := reflect.TypeOf(DrugInfo{}).FieldByName(field); ok {
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" {
+			return name
+		}
+	}
+	return field
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
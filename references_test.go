@@ -0,0 +1,81 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseReference(t *testing.T) {
+	page := fixture(t, `<ol>
+		<li id="reference-A1234">Smith J, Doe A: Aspirin and platelets. J Pharm. 2002 Mar;12(3):45-9. doi: 10.1016/j.jp.2002.01.003. <a href="https://pubmed.ncbi.nlm.nih.gov/12345">[Article]</a></li>
+		<li id="reference-L567"><a href="https://www.fda.gov/label.pdf">FDA Label: Aspirin</a> [Link]</li>
+		<li id="reference-T89">Brunton L: Goodman and Gilman's The Pharmacological Basis of Therapeutics. 12th ed. 2011.</li>
+		<li>Unkeyed entry</li>
+	</ol>`)
+
+	var references []Reference
+	page.Find("li").Each(func(_ int, li *goquery.Selection) {
+		if reference, ok := parseReference(li); ok {
+			references = append(references, reference)
+		}
+	})
+	if len(references) != 3 {
+		t.Fatalf("got %d references, want 3: %+v", len(references), references)
+	}
+
+	tests := []Reference{
+		{Key: "A1234", Kind: "article", Title: "Aspirin and platelets", PubMedID: "12345", DOI: "10.1016/j.jp.2002.01.003",
+			Citation: "Smith J, Doe A: Aspirin and platelets. J Pharm. 2002 Mar;12(3):45-9. doi: 10.1016/j.jp.2002.01.003."},
+		{Key: "L567", Kind: "link", Title: "FDA Label: Aspirin", URL: "https://www.fda.gov/label.pdf", Citation: "FDA Label: Aspirin"},
+		{Key: "T89", Kind: "textbook", Title: "Goodman and Gilman's The Pharmacological Basis of Therapeutics",
+			Citation: "Brunton L: Goodman and Gilman's The Pharmacological Basis of Therapeutics. 12th ed. 2011."},
+	}
+	for i, want := range tests {
+		if references[i] != want {
+			t.Errorf("reference %d = %+v, want %+v", i, references[i], want)
+		}
+	}
+}
+
+func TestHandleReferenceMarkers(t *testing.T) {
+	mode := ReferenceMarkers
+	defer func() { ReferenceMarkers = mode }()
+
+	references := []Reference{{Key: "A1", PubMedID: "111"}, {Key: "A2", DOI: "10.1000/xyz"}, {Key: "L3"}}
+	tests := []struct {
+		mode            string
+		wantIndication  string
+		wantDescription string
+	}{
+		{MARKERS_STRIP, "Used for pain.", "Aspirin inhibits COX."},
+		{MARKERS_LINK, "Used for pain [A1](https://pubmed.ncbi.nlm.nih.gov/111), [A2](https://doi.org/10.1000/xyz).", "Aspirin inhibits COX [L3]."},
+		{MARKERS_KEEP, "Used for pain [A1, A2].", "Aspirin inhibits COX [L3]."},
+	}
+	for _, tt := range tests {
+		ReferenceMarkers = tt.mode
+		drugInfo := DrugInfo{
+			Indication:  "Used for pain [A1, A2].",
+			Description: "Aspirin inhibits COX [L3].",
+			Summary:     "No markers here.",
+			// made up, but exact fields must never be touched
+			Smiles:     "CC(=O)Oc1ccccc1C(O)=O[A1]",
+			References: references,
+		}
+		if err := handleReferenceMarkers(nil, &drugInfo); err != nil {
+			t.Fatal(err)
+		}
+
+		if drugInfo.Indication != tt.wantIndication || drugInfo.Description != tt.wantDescription {
+			t.Errorf("%s: indication %q, description %q, want %q, %q", tt.mode, drugInfo.Indication, drugInfo.Description, tt.wantIndication, tt.wantDescription)
+		}
+		if drugInfo.Smiles != "CC(=O)Oc1ccccc1C(O)=O[A1]" {
+			t.Errorf("%s: SMILES rewritten to %q", tt.mode, drugInfo.Smiles)
+		}
+		indication, description := jsonFieldName("Indication"), jsonFieldName("Description")
+		if !slices.Equal(drugInfo.Citations[indication], []string{"A1", "A2"}) || !slices.Equal(drugInfo.Citations[description], []string{"L3"}) || len(drugInfo.Citations) != 2 {
+			t.Errorf("%s: citations = %v", tt.mode, drugInfo.Citations)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sdfTags are the DrugInfo fields written as SD tags after each structure, in order.
+var sdfTags = []struct {
+	Name  string
+	Value func(DrugInfo) string
+}{
+	{"DRUGBANK_ID", func(d DrugInfo) string { return d.ID }},
+	{"NAME", func(d DrugInfo) string { return d.Molecule }},
+	{"CAS", func(d DrugInfo) string { return d.CAS }},
+	{"GROUPS", func(d DrugInfo) string { return strings.Join(d.Groups, "; ") }},
+	{"INCHIKEY", func(d DrugInfo) string { return d.InChIKey }},
+	{"FORMULA", func(d DrugInfo) string { return d.Formula }},
+	{"SMILES", func(d DrugInfo) string { return d.Smiles }},
+}
+
+// writeSDF writes the MOL files the scrape downloaded with --structures as one SD file, every
+// record titled with the DrugBank ID and tagged with sdfTags. Drugs without a structure file
+// are left out.
+func writeSDF(w io.Writer, drugInfos []DrugInfo, assetsDir string) error {
+	bw := bufio.NewWriter(w)
+	written := 0
+	for _, drugInfo := range drugInfos {
+		path := assetPath(drugInfo, "structure_mol")
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(assetsDir, path))
+		if err != nil {
+			log.Printf("🔥 %s: failed to read structure file: %v\n", drugInfo.ID, err)
+			continue
+		}
+		block, ok := molBlock(string(data))
+		if !ok {
+			log.Printf("🔥 %s: %s is not a molfile, skipping\n", drugInfo.ID, path)
+			continue
+		}
+
+		block[0] = drugInfo.ID
+		bw.WriteString(strings.Join(block, "\n") + "\n")
+		for _, tag := range sdfTags {
+			if value := strings.TrimSpace(tag.Value(drugInfo)); value != "" {
+				fmt.Fprintf(bw, "> <%s>\n%s\n\n", tag.Name, value)
+			}
+		}
+		bw.WriteString("$$$$\n")
+		written++
+	}
+	log.Printf("ℹ️ Wrote %d of %d drugs to the SD file, %d have no structure file\n", written, len(drugInfos), len(drugInfos)-written)
+	return bw.Flush()
+}
+
+// molBlock returns the lines of the first molfile in data up to and including "M  END",
+// dropping any SD tags of a downloaded .sdf.
+func molBlock(data string) ([]string, bool) {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "M  END") {
+			// header block of three lines plus the counts line
+			if i < 4 {
+				return nil, false
+			}
+			return lines[:i+1], true
+		}
+	}
+	return nil, false
+}
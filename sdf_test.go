@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const aspirinMolfile = `2244
+  -OEChem-01012400002D
+
+  3  2  0     0  0  0  0  0  0999 V2000
+    0.0000    0.0000    0.0000 C   0  0  0  0  0  0  0  0  0  0  0  0
+    1.0000    0.0000    0.0000 O   0  0  0  0  0  0  0  0  0  0  0  0
+    2.0000    0.0000    0.0000 O   0  0  0  0  0  0  0  0  0  0  0  0
+  1  2  2  0  0  0  0
+  1  3  1  0  0  0  0
+M  END
+`
+
+func TestMolBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantLines int
+		wantOK    bool
+	}{
+		{"molfile", aspirinMolfile, 10, true},
+		{"crlf", strings.ReplaceAll(aspirinMolfile, "\n", "\r\n"), 10, true},
+		{"sd record", aspirinMolfile + "> <PUBCHEM_COMPOUND_CID>\n2244\n\n$$$$\n", 10, true},
+		{"no header", "  3  2  0\nM  END\n", 0, false},
+		{"sign in page", "<html>Sign in</html>", 0, false},
+	}
+	for _, tt := range tests {
+		block, ok := molBlock(tt.data)
+		if ok != tt.wantOK || len(block) != tt.wantLines {
+			t.Errorf("%s: molBlock() = %d lines, %v, want %d lines, %v", tt.name, len(block), ok, tt.wantLines, tt.wantOK)
+		}
+		if ok && block[len(block)-1] != "M  END" {
+			t.Errorf("%s: block ends with %q", tt.name, block[len(block)-1])
+		}
+	}
+}
+
+func TestWriteSDF(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ab.mol"), []byte(aspirinMolfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "login.mol"), []byte("<html>Sign in</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	drugs := []DrugInfo{
+		{ID: "DB00945", Molecule: "Acetylsalicylic acid", CAS: "50-78-2", Groups: []string{"Approved", "Vet approved"}, Formula: "C9H8O4",
+			Assets: []Asset{{Kind: "structure_svg", Path: "cd.svg"}, {Kind: "structure_mol", Path: "ab.mol"}}},
+		{ID: "DB00001", Molecule: "Lepirudin"},
+		{ID: "DB00002", Assets: []Asset{{Kind: "structure_mol", Path: "login.mol"}}},
+		{ID: "DB00003", Assets: []Asset{{Kind: "structure_mol", Path: "gone.mol"}}},
+	}
+	var out strings.Builder
+	if err := writeSDF(&out, drugs, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "DB00945\n" + strings.SplitN(aspirinMolfile, "\n", 2)[1] +
+		"> <DRUGBANK_ID>\nDB00945\n\n" +
+		"> <NAME>\nAcetylsalicylic acid\n\n" +
+		"> <CAS>\n50-78-2\n\n" +
+		"> <GROUPS>\nApproved; Vet approved\n\n" +
+		"> <FORMULA>\nC9H8O4\n\n" +
+		"$$$$\n"
+	if out.String() != want {
+		t.Errorf("writeSDF() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const JSON_LINES_PATTERN = "drugs-%04d.ndjson"
+
+// Sink receives every scraped drug the moment it is delivered.
+type Sink interface {
+	Write(drugInfo DrugInfo) error
+	Close() error
+}
+
+// JSONLinesSink writes each DrugInfo as one NDJSON line, so the results can be
+// tailed while the crawl is running. Files are named drugs-0001.ndjson and on,
+// a new one is started every rotateLines lines (never if 0), and an existing run
+// directory is appended to.
+type JSONLinesSink struct {
+	mu          sync.Mutex
+	dir         string
+	fsync       bool
+	rotateLines int
+
+	file  *os.File
+	buf   *bufio.Writer
+	index int
+	lines int
+}
+
+func NewJSONLinesSink(dir string, fsync bool, rotateLines int) (*JSONLinesSink, error) {
+	s := &JSONLinesSink{
+		dir:         dir,
+		fsync:       fsync,
+		rotateLines: rotateLines,
+		index:       1,
+	}
+
+	// continue in the last file of a previous session
+	existing, err := jsonLinesFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		last := existing[len(existing)-1]
+		fmt.Sscanf(filepath.Base(last), JSON_LINES_PATTERN, &s.index)
+		if err := trimPartialLine(last); err != nil {
+			return nil, err
+		}
+		if s.lines, err = countLines(last); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *JSONLinesSink) open() error {
+	path := filepath.Join(s.dir, fmt.Sprintf(JSON_LINES_PATTERN, s.index))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.buf = bufio.NewWriter(file)
+	return nil
+}
+
+func (s *JSONLinesSink) rotate() error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	s.index++
+	s.lines = 0
+	return s.open()
+}
+
+func (s *JSONLinesSink) Write(drugInfo DrugInfo) error {
+	line, err := json.Marshal(drugInfo)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rotateLines > 0 && s.lines >= s.rotateLines {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.buf.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.lines++
+
+	// flush every line so readers tailing the file always see whole records
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if s.fsync {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *JSONLinesSink) closeFile() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeFile()
+}
+
+// jsonLinesFiles lists the NDJSON result files of a run directory in write order.
+func jsonLinesFiles(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "drugs-*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// trimPartialLine truncates path back to its last newline, dropping the half written record
+// a killed session leaves behind so the next record isn't glued onto it.
+func trimPartialLine(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// read backwards in chunks until a newline turns up
+	chunk := make([]byte, 64*1024)
+	end := info.Size()
+	for end > 0 {
+		start := end - int64(len(chunk))
+		if start < 0 {
+			start = 0
+		}
+		n, err := file.ReadAt(chunk[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if i := bytes.LastIndexByte(chunk[:n], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+
+	if end == info.Size() {
+		return nil
+	}
+	log.Printf("🔥 Dropping %d bytes of a partial record at the end of %s\n", info.Size()-end, path)
+	return file.Truncate(end)
+}
+
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	lines := 0
+	reader := bufio.NewReader(file)
+	for {
+		_, err := reader.ReadSlice('\n')
+		if err == nil {
+			lines++
+			continue
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		return lines, err
+	}
+}
+
+// readJSONLines calls fn for every drug in the given NDJSON files, one at a time.
+func readJSONLines(paths []string, fn func(DrugInfo) error) error {
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+			var drugInfo DrugInfo
+			if err := json.Unmarshal(scanner.Bytes(), &drugInfo); err != nil {
+				file.Close()
+				return fmt.Errorf("readJSONLines(): %s line %d: %v", path, line, err)
+			}
+			if err := fn(drugInfo); err != nil {
+				file.Close()
+				return err
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("readJSONLines(): %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// writeJSONArray streams the drugs of the given NDJSON files into one indented JSON
+// array, the format saveToFile used to produce, without holding them all in memory.
+func writeJSONArray(w io.Writer, paths []string) error {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("["); err != nil {
+		return err
+	}
+
+	first := true
+	err := readJSONLines(paths, func(drugInfo DrugInfo) error {
+		jsonData, err := json.MarshalIndent(drugInfo, "    ", "    ")
+		if err != nil {
+			return err
+		}
+		if !first {
+			buf.WriteString(",")
+		}
+		first = false
+		buf.WriteString("\n    ")
+		_, err = buf.Write(jsonData)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !first {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]")
+	return buf.Flush()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONLinesSinkResumeDropsPartialRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     []string
+	}{
+		{"complete lines", `{"id":"DB00001"}` + "\n" + `{"id":"DB00002"}` + "\n", []string{"DB00001", "DB00002", "DB00003"}},
+		{"partial last line", `{"id":"DB00001"}` + "\n" + `{"id":"DB000`, []string{"DB00001", "DB00003"}},
+		{"only a partial line", `{"id":"DB0`, []string{"DB00003"}},
+		{"empty file", "", []string{"DB00003"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "drugs-0001.ndjson")
+			if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sink, err := NewJSONLinesSink(dir, false, 0)
+			if err != nil {
+				t.Fatalf("NewJSONLinesSink(): %v", err)
+			}
+			if sink.lines != len(tt.want)-1 {
+				t.Errorf("sink resumed at %d lines, want %d", sink.lines, len(tt.want)-1)
+			}
+			if err := sink.Write(DrugInfo{ID: "DB00003"}); err != nil {
+				t.Fatalf("Write(): %v", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("Close(): %v", err)
+			}
+
+			var got []string
+			if err := readJSONLines([]string{path}, func(drugInfo DrugInfo) error {
+				got = append(got, drugInfo.ID)
+				return nil
+			}); err != nil {
+				t.Fatalf("readJSONLines(): %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("read %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("record %d is %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
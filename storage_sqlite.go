@@ -0,0 +1,650 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const SQLITE_SCHEMA = `
+CREATE TABLE IF NOT EXISTS drugs (
+	id                         TEXT PRIMARY KEY,
+	molecule                   TEXT,
+	cas                        TEXT,
+	type                       TEXT,
+	link                       TEXT,
+	is_stub                    INTEGER,
+	smiles                     TEXT,
+	inchi                      TEXT,
+	inchi_hash                 TEXT,
+	inchi_key                  TEXT,
+	iupac_name                 TEXT,
+	formula                    TEXT,
+	average_weight             REAL,
+	monoisotopic_weight        REAL,
+	summary                    TEXT,
+	description                TEXT,
+	background                 TEXT,
+	indication                 TEXT,
+	pharmacodynamics           TEXT,
+	adverse_effects            TEXT,
+	half_life                  TEXT,
+	route_of_elimination       TEXT,
+	toxicity                   TEXT,
+	clearance                  TEXT,
+	absorption                 TEXT,
+	volume_of_distribution     TEXT,
+	protein_binding            TEXT,
+	scraped_at                 INTEGER,
+	drug_interactions_total    INTEGER,
+	drug_interactions_complete INTEGER,
+	clinical_trials            INTEGER,
+	clinical_trials_complete   INTEGER,
+	kingdom                    TEXT,
+	super_class                TEXT,
+	class                      TEXT,
+	sub_class                  TEXT,
+	direct_parent              TEXT,
+	molecular_framework        TEXT
+);
+
+CREATE TABLE IF NOT EXISTS drug_synonyms (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	synonym  TEXT NOT NULL,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_categories (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	category    TEXT NOT NULL,
+	category_id TEXT,
+	mesh_id     TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_atc_codes (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	atc_code TEXT NOT NULL,
+	level    INTEGER NOT NULL,
+	code     TEXT NOT NULL,
+	name     TEXT,
+	PRIMARY KEY (drug_id, atc_code, level)
+);
+
+CREATE INDEX IF NOT EXISTS drug_atc_codes_code ON drug_atc_codes (code);
+
+CREATE TABLE IF NOT EXISTS drug_cross_references (
+	drug_id     TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	source      TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	external_id TEXT,
+	link        TEXT,
+	PRIMARY KEY (drug_id, source, position)
+);
+
+CREATE INDEX IF NOT EXISTS drug_cross_references_external_id ON drug_cross_references (source, external_id);
+
+CREATE TABLE IF NOT EXISTS drug_properties (
+	drug_id     TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	kind        TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	name        TEXT NOT NULL,
+	label       TEXT,
+	value       TEXT,
+	numeric     REAL,
+	numeric_max REAL,
+	units       TEXT,
+	boolean     INTEGER,
+	source      TEXT,
+	PRIMARY KEY (drug_id, kind, position)
+);
+
+CREATE INDEX IF NOT EXISTS drug_properties_name ON drug_properties (name, numeric);
+
+CREATE TABLE IF NOT EXISTS drug_taxonomy_terms (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	kind     TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	term     TEXT NOT NULL,
+	PRIMARY KEY (drug_id, kind, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_pharmacokinetics (
+	drug_id          TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	parameter        TEXT NOT NULL,
+	raw              TEXT,
+	min              REAL,
+	max              REAL,
+	units            TEXT,
+	approximate      INTEGER,
+	bound            TEXT,
+	normalized_min   REAL,
+	normalized_max   REAL,
+	normalized_units TEXT,
+	PRIMARY KEY (drug_id, parameter)
+);
+
+CREATE TABLE IF NOT EXISTS drug_references (
+	drug_id   TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	ref_key   TEXT NOT NULL,
+	kind      TEXT,
+	title     TEXT,
+	pubmed_id TEXT,
+	doi       TEXT,
+	url       TEXT,
+	citation  TEXT,
+	PRIMARY KEY (drug_id, ref_key)
+);
+
+CREATE INDEX IF NOT EXISTS drug_references_pubmed_id ON drug_references (pubmed_id);
+
+CREATE TABLE IF NOT EXISTS drug_citations (
+	drug_id TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	field   TEXT NOT NULL,
+	ref_key TEXT NOT NULL,
+	PRIMARY KEY (drug_id, field, ref_key)
+);
+
+CREATE TABLE IF NOT EXISTS drug_clinical_trials (
+	drug_id      TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	phase        TEXT, -- comma separated for combined phases, "1,2"
+	status       TEXT,
+	purpose      TEXT,
+	condition    TEXT,
+	condition_id TEXT,
+	count        INTEGER,
+	link         TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS drug_clinical_trials_condition ON drug_clinical_trials (condition_id, phase);
+
+CREATE TABLE IF NOT EXISTS drug_assets (
+	drug_id TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	kind    TEXT NOT NULL,
+	url     TEXT,
+	path    TEXT,
+	sha256  TEXT,
+	bytes   INTEGER,
+	PRIMARY KEY (drug_id, kind)
+);
+
+CREATE TABLE IF NOT EXISTS drug_groups (
+	drug_id    TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	drug_group TEXT NOT NULL,
+	PRIMARY KEY (drug_id, drug_group)
+);
+
+CREATE TABLE IF NOT EXISTS drug_moa (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	target   TEXT,
+	action   TEXT,
+	organism TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_interactions (
+	drug_id      TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	partner_id   TEXT,
+	partner_name TEXT,
+	description  TEXT,
+	effect       TEXT,
+	property     TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS drug_interactions_partner ON drug_interactions (partner_id);
+
+CREATE TABLE IF NOT EXISTS drug_food_interactions (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	category TEXT NOT NULL,
+	text     TEXT NOT NULL,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_bio_interactors (
+	drug_id           TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position          INTEGER NOT NULL,
+	kind              TEXT NOT NULL,
+	be_id             TEXT,
+	name              TEXT,
+	type              TEXT,
+	organism          TEXT,
+	known_action      TEXT,
+	actions           TEXT,
+	gene_name         TEXT,
+	uniprot_id        TEXT,
+	uniprot_name      TEXT,
+	general_function  TEXT,
+	specific_function TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS drug_bio_interactors_be_id ON drug_bio_interactors (be_id);
+
+CREATE TABLE IF NOT EXISTS drug_products (
+	drug_id         TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position        INTEGER NOT NULL,
+	kind            TEXT NOT NULL,
+	name            TEXT NOT NULL,
+	ingredients     TEXT,
+	dosage_form     TEXT,
+	strength        TEXT,
+	route           TEXT,
+	labeller        TEXT,
+	marketing_start TEXT,
+	marketing_end   TEXT,
+	country         TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_brands (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	company  TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_packagers (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	packager TEXT NOT NULL,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_manufacturers (
+	drug_id      TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	manufacturer TEXT NOT NULL,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_dosage_forms (
+	drug_id  TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	form     TEXT NOT NULL,
+	route    TEXT,
+	strength TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS drug_prices (
+	drug_id     TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE,
+	position    INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	cost        REAL,
+	currency    TEXT,
+	unit        TEXT,
+	raw         TEXT,
+	PRIMARY KEY (drug_id, position)
+);
+`
+
+// columns added to tables after their first release, added to older databases on open
+var sqliteAddedColumns = map[string][][2]string{
+	"drugs": {
+		{"drug_interactions_total", "INTEGER"},
+		{"drug_interactions_complete", "INTEGER"},
+		{"kingdom", "TEXT"},
+		{"super_class", "TEXT"},
+		{"class", "TEXT"},
+		{"sub_class", "TEXT"},
+		{"direct_parent", "TEXT"},
+		{"molecular_framework", "TEXT"},
+		{"volume_of_distribution", "TEXT"},
+		{"protein_binding", "TEXT"},
+		{"clinical_trials", "INTEGER"},
+		{"clinical_trials_complete", "INTEGER"},
+	},
+	"drug_categories": {
+		{"category_id", "TEXT"},
+		{"mesh_id", "TEXT"},
+	},
+	"drug_interactions": {
+		{"effect", "TEXT"},
+		{"property", "TEXT"},
+	},
+	"drug_pharmacokinetics": {
+		{"bound", "TEXT"},
+	},
+}
+
+// child tables rewritten on every upsert of a drug
+var sqliteChildTables = []string{"drug_synonyms", "drug_categories", "drug_atc_codes", "drug_cross_references", "drug_properties", "drug_taxonomy_terms", "drug_pharmacokinetics", "drug_references", "drug_citations", "drug_clinical_trials", "drug_assets", "drug_groups", "drug_moa", "drug_interactions", "drug_food_interactions", "drug_bio_interactors",
+	"drug_products", "drug_brands", "drug_packagers", "drug_manufacturers", "drug_dosage_forms", "drug_prices",
+}
+
+// SQLiteSink persists drugs into a normalized SQLite database. Drugs are upserted by
+// DrugBank ID, so scraping the same drug again updates its rows instead of duplicating them.
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+func NewSQLiteSink(path string) (*SQLiteSink, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, err
+	}
+	// sqlite only has one writer anyway
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(SQLITE_SCHEMA); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("NewSQLiteSink(): failed to create schema: %v", err)
+	}
+	for table, columns := range sqliteAddedColumns {
+		if err := addMissingColumns(db, table, columns); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("NewSQLiteSink(): failed to migrate schema: %v", err)
+		}
+	}
+	return &SQLiteSink{db: db}, nil
+}
+
+// addMissingColumns brings a table created by an older version up to date.
+func addMissingColumns(db *sql.DB, table string, columns [][2]string) error {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column[0]] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column[0], column[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drugColumns returns the columns of the drugs table and their values for drugInfo.
+func drugColumns(drugInfo DrugInfo) ([]string, []any) {
+	var average, monoisotopic sql.NullFloat64
+	for _, weight := range drugInfo.Weight {
+		switch weight.Type {
+		case "average":
+			average = sql.NullFloat64{Float64: weight.Weight, Valid: true}
+		case "monoisotopic":
+			monoisotopic = sql.NullFloat64{Float64: weight.Weight, Valid: true}
+		}
+	}
+
+	var trials, trialsComplete sql.NullInt64
+	if drugInfo.ClinicalTrials != nil {
+		trials = sql.NullInt64{Int64: int64(drugInfo.ClinicalTrials.Trials), Valid: true}
+		trialsComplete = sql.NullInt64{Int64: 0, Valid: true}
+		if drugInfo.ClinicalTrials.Complete {
+			trialsComplete.Int64 = 1
+		}
+	}
+
+	taxonomy := ChemicalTaxonomy{}
+	if drugInfo.Taxonomy != nil {
+		taxonomy = *drugInfo.Taxonomy
+	}
+
+	columns := []string{
+		"id", "molecule", "cas", "type", "link", "is_stub",
+		"smiles", "inchi", "inchi_hash", "inchi_key", "iupac_name", "formula",
+		"average_weight", "monoisotopic_weight",
+		"summary", "description", "background", "indication", "pharmacodynamics",
+		"adverse_effects", "half_life", "route_of_elimination", "toxicity", "clearance", "absorption",
+		"volume_of_distribution", "protein_binding",
+		"scraped_at",
+		"drug_interactions_total", "drug_interactions_complete",
+		"clinical_trials", "clinical_trials_complete",
+		"kingdom", "super_class", "class", "sub_class", "direct_parent", "molecular_framework",
+	}
+	values := []any{
+		drugInfo.ID, drugInfo.Molecule, drugInfo.CAS, drugInfo.Type, drugInfo.Link, drugInfo.IsStub,
+		drugInfo.Smiles, drugInfo.InChI.ID, drugInfo.InChI.Hash, drugInfo.InChIKey, drugInfo.IupacName, drugInfo.Formula,
+		average, monoisotopic,
+		drugInfo.Summary, drugInfo.Description, drugInfo.Background, drugInfo.Indication, drugInfo.Pharmacodynamics,
+		drugInfo.AdverseEffects, drugInfo.HalfLife, drugInfo.RouteOfElimination, drugInfo.Toxicity, drugInfo.Clearance, drugInfo.Absorption,
+		drugInfo.VolumeOfDistribution, drugInfo.ProteinBinding,
+		time.Now().Unix(),
+		drugInfo.DrugInteractionsTotal, drugInfo.DrugInteractionsComplete,
+		trials, trialsComplete,
+		taxonomy.Kingdom, taxonomy.SuperClass, taxonomy.Class, taxonomy.SubClass, taxonomy.DirectParent, taxonomy.MolecularFramework,
+	}
+	return columns, values
+}
+
+func upsertStatement(table string, key string, columns []string) string {
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column != key {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT(%s) DO UPDATE SET %s",
+		table,
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+		key,
+		strings.Join(updates, ", "),
+	)
+}
+
+func (s *SQLiteSink) Write(drugInfo DrugInfo) error {
+	if drugInfo.ID == "" {
+		return fmt.Errorf("SQLiteSink.Write(): drug %s has no DrugBank ID", drugInfo.Link)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns, values := drugColumns(drugInfo)
+	if _, err := tx.Exec(upsertStatement("drugs", "id", columns), values...); err != nil {
+		return fmt.Errorf("SQLiteSink.Write(): %s: %v", drugInfo.ID, err)
+	}
+
+	for _, table := range sqliteChildTables {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE drug_id = ?", table), drugInfo.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writeChildren(tx, drugInfo); err != nil {
+		return fmt.Errorf("SQLiteSink.Write(): %s: %v", drugInfo.ID, err)
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteSink) writeChildren(tx *sql.Tx, drugInfo DrugInfo) error {
+	id := drugInfo.ID
+
+	for i, synonym := range drugInfo.Synonyms {
+		if _, err := tx.Exec("INSERT INTO drug_synonyms (drug_id, position, synonym) VALUES (?, ?, ?)", id, i, synonym); err != nil {
+			return err
+		}
+	}
+	categories := drugInfo.DrugCategories
+	if len(categories) == 0 {
+		// results scraped before categories kept their IDs
+		for _, name := range drugInfo.Categories {
+			categories = append(categories, DrugCategory{Name: name})
+		}
+	}
+	for i, category := range categories {
+		if _, err := tx.Exec("INSERT INTO drug_categories (drug_id, position, category, category_id, mesh_id) VALUES (?, ?, ?, ?, ?)", id, i, category.Name, category.ID, category.MeshID); err != nil {
+			return err
+		}
+	}
+	for _, atc := range drugInfo.ATCCodes {
+		for _, level := range atc.Levels {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO drug_atc_codes (drug_id, atc_code, level, code, name) VALUES (?, ?, ?, ?, ?)", id, atc.Code, level.Level, level.Code, level.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for source, ids := range drugInfo.CrossReferences {
+		for i, ref := range ids {
+			if _, err := tx.Exec("INSERT INTO drug_cross_references (drug_id, source, position, external_id, link) VALUES (?, ?, ?, ?, ?)", id, string(source), i, ref.ID, ref.Link); err != nil {
+				return err
+			}
+		}
+	}
+	for _, properties := range [][]ChemicalProperty{drugInfo.ExperimentalProperties, drugInfo.PredictedProperties} {
+		for i, p := range properties {
+			if _, err := tx.Exec(
+				"INSERT INTO drug_properties (drug_id, kind, position, name, label, value, numeric, numeric_max, units, boolean, source) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				id, p.Kind, i, p.Name, p.Label, p.Value, p.Numeric, p.NumericMax, p.Units, p.Boolean, p.Source,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	if taxonomy := drugInfo.Taxonomy; taxonomy != nil {
+		for kind, terms := range map[string][]string{
+			"alternative_parent":  taxonomy.AlternativeParents,
+			"substituent":         taxonomy.Substituents,
+			"external_descriptor": taxonomy.ExternalDescriptors,
+		} {
+			for i, term := range terms {
+				if _, err := tx.Exec("INSERT INTO drug_taxonomy_terms (drug_id, kind, position, term) VALUES (?, ?, ?, ?)", id, kind, i, term); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if drugInfo.Pharmacokinetics != nil {
+		for parameter, value := range drugInfo.Pharmacokinetics.pkParameters() {
+			if value == nil {
+				continue
+			}
+			if _, err := tx.Exec(
+				"INSERT INTO drug_pharmacokinetics (drug_id, parameter, raw, min, max, units, approximate, bound, normalized_min, normalized_max, normalized_units) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				id, parameter, value.Raw, value.Min, value.Max, value.Units, value.Approximate, value.Bound, value.NormalizedMin, value.NormalizedMax, value.NormalizedUnits,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ref := range drugInfo.References {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO drug_references (drug_id, ref_key, kind, title, pubmed_id, doi, url, citation) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			id, ref.Key, ref.Kind, ref.Title, ref.PubMedID, ref.DOI, ref.URL, ref.Citation,
+		); err != nil {
+			return err
+		}
+	}
+	for field, keys := range drugInfo.Citations {
+		for _, key := range keys {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO drug_citations (drug_id, field, ref_key) VALUES (?, ?, ?)", id, field, key); err != nil {
+				return err
+			}
+		}
+	}
+	if drugInfo.ClinicalTrials != nil {
+		for i, group := range drugInfo.ClinicalTrials.Groups {
+			if _, err := tx.Exec(
+				"INSERT INTO drug_clinical_trials (drug_id, position, phase, status, purpose, condition, condition_id, count, link) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				id, i, strings.Join(group.Phases, ","), group.Status, group.Purpose, group.Condition, group.ConditionID, group.Count, group.Link,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	for _, asset := range drugInfo.Assets {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO drug_assets (drug_id, kind, url, path, sha256, bytes) VALUES (?, ?, ?, ?, ?, ?)", id, asset.Kind, asset.URL, asset.Path, asset.SHA256, asset.Bytes); err != nil {
+			return err
+		}
+	}
+	for _, group := range drugInfo.Groups {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO drug_groups (drug_id, drug_group) VALUES (?, ?)", id, group); err != nil {
+			return err
+		}
+	}
+	for i, moa := range drugInfo.Moa {
+		if _, err := tx.Exec("INSERT INTO drug_moa (drug_id, position, target, action, organism) VALUES (?, ?, ?, ?, ?)", id, i, moa["target"], moa["action"], moa["organism"]); err != nil {
+			return err
+		}
+	}
+	for i, interaction := range drugInfo.DrugInteractions {
+		if _, err := tx.Exec("INSERT INTO drug_interactions (drug_id, position, partner_id, partner_name, description, effect, property) VALUES (?, ?, ?, ?, ?, ?, ?)", id, i, interaction.PartnerID, interaction.PartnerName, interaction.Description, interaction.Effect, interaction.Property); err != nil {
+			return err
+		}
+	}
+	for i, food := range drugInfo.FoodInteractions {
+		if _, err := tx.Exec("INSERT INTO drug_food_interactions (drug_id, position, category, text) VALUES (?, ?, ?, ?)", id, i, food.Category, food.Text); err != nil {
+			return err
+		}
+	}
+	for i, b := range drugInfo.BioInteractors {
+		if _, err := tx.Exec(
+			"INSERT INTO drug_bio_interactors (drug_id, position, kind, be_id, name, type, organism, known_action, actions, gene_name, uniprot_id, uniprot_name, general_function, specific_function) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			id, i, b.Kind, b.ID, b.Name, b.Type, b.Organism, b.KnownAction, strings.Join(b.Actions, "; "), b.GeneName, b.UniProtID, b.UniProtName, b.GeneralFunction, b.SpecificFunction,
+		); err != nil {
+			return err
+		}
+	}
+	for i, p := range drugInfo.Products {
+		if _, err := tx.Exec(
+			"INSERT INTO drug_products (drug_id, position, kind, name, ingredients, dosage_form, strength, route, labeller, marketing_start, marketing_end, country) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			id, i, p.Kind, p.Name, strings.Join(p.Ingredients, "; "), p.DosageForm, p.Strength, p.Route, p.Labeller, p.MarketingStart, p.MarketingEnd, p.Country,
+		); err != nil {
+			return err
+		}
+	}
+	for i, brand := range drugInfo.InternationalBrands {
+		if _, err := tx.Exec("INSERT INTO drug_brands (drug_id, position, name, company) VALUES (?, ?, ?, ?)", id, i, brand.Name, brand.Company); err != nil {
+			return err
+		}
+	}
+	for i, packager := range drugInfo.Packagers {
+		if _, err := tx.Exec("INSERT INTO drug_packagers (drug_id, position, packager) VALUES (?, ?, ?)", id, i, packager); err != nil {
+			return err
+		}
+	}
+	for i, manufacturer := range drugInfo.Manufacturers {
+		if _, err := tx.Exec("INSERT INTO drug_manufacturers (drug_id, position, manufacturer) VALUES (?, ?, ?)", id, i, manufacturer); err != nil {
+			return err
+		}
+	}
+	for i, form := range drugInfo.DosageForms {
+		if _, err := tx.Exec("INSERT INTO drug_dosage_forms (drug_id, position, form, route, strength) VALUES (?, ?, ?, ?, ?)", id, i, form.Form, form.Route, form.Strength); err != nil {
+			return err
+		}
+	}
+	for i, price := range drugInfo.Prices {
+		cost := sql.NullFloat64{Float64: price.Cost, Valid: price.Currency != ""}
+		if _, err := tx.Exec("INSERT INTO drug_prices (drug_id, position, description, cost, currency, unit, raw) VALUES (?, ?, ?, ?, ?, ?, ?)", id, i, price.Description, cost, price.Currency, price.Unit, price.Raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteSinkUpsert(t *testing.T) {
+	sink, err := NewSQLiteSink(filepath.Join(t.TempDir(), "drugs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	drug := DrugInfo{
+		ID:       "DB00945",
+		Molecule: "Acetylsalicylic acid",
+		Synonyms: []string{"Aspirin", "ASA", "2-Acetoxybenzoic acid"},
+		Groups:   []string{"Approved", "Vet approved"},
+		DrugInteractions: []Interaction{
+			{PartnerID: "DB00006", PartnerName: "Bivalirudin", Description: "The risk or severity of bleeding can be increased.", Effect: "increase", Property: "risk or severity of bleeding"},
+		},
+		FoodInteractions: []FoodInteraction{{Category: "avoid_alcohol", Text: "Avoid alcohol."}},
+	}
+	if err := sink.Write(drug); err != nil {
+		t.Fatalf("first Write(): %v", err)
+	}
+
+	// the second scrape renamed the drug and lost a synonym
+	drug.Molecule = "Aspirin"
+	drug.Synonyms = drug.Synonyms[:2]
+	if err := sink.Write(drug); err != nil {
+		t.Fatalf("second Write(): %v", err)
+	}
+
+	counts := map[string]int{"drugs": 1, "drug_synonyms": 2, "drug_groups": 2, "drug_interactions": 1, "drug_food_interactions": 1}
+	for table, want := range counts {
+		var got int
+		if err := sink.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s has %d rows, want %d", table, got, want)
+		}
+	}
+	var molecule string
+	if err := sink.db.QueryRow("SELECT molecule FROM drugs WHERE id = ?", drug.ID).Scan(&molecule); err != nil || molecule != "Aspirin" {
+		t.Errorf("molecule = %q, %v, want Aspirin", molecule, err)
+	}
+
+	if err := sink.Write(DrugInfo{Molecule: "no ID"}); err == nil {
+		t.Error("Write() of a drug without ID succeeded")
+	}
+}
+
+func TestSQLiteSinkMigratesOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tables as the first SQLite version created them, before any of sqliteAddedColumns
+	_, err = db.Exec(`
+		CREATE TABLE drugs (id TEXT PRIMARY KEY, molecule TEXT, cas TEXT, type TEXT, link TEXT, is_stub INTEGER,
+			smiles TEXT, inchi TEXT, inchi_hash TEXT, inchi_key TEXT, iupac_name TEXT, formula TEXT,
+			average_weight REAL, monoisotopic_weight REAL, summary TEXT, description TEXT, background TEXT,
+			indication TEXT, pharmacodynamics TEXT, adverse_effects TEXT, half_life TEXT, route_of_elimination TEXT,
+			toxicity TEXT, clearance TEXT, absorption TEXT, scraped_at INTEGER);
+		CREATE TABLE drug_categories (drug_id TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE, position INTEGER NOT NULL, category TEXT NOT NULL, PRIMARY KEY (drug_id, position));
+		CREATE TABLE drug_interactions (drug_id TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE, position INTEGER NOT NULL, partner_id TEXT, partner_name TEXT, description TEXT, PRIMARY KEY (drug_id, position));
+		CREATE TABLE drug_pharmacokinetics (drug_id TEXT NOT NULL REFERENCES drugs(id) ON DELETE CASCADE, parameter TEXT NOT NULL, raw TEXT, min REAL, max REAL, units TEXT, approximate INTEGER, normalized_min REAL, normalized_max REAL, normalized_units TEXT, PRIMARY KEY (drug_id, parameter));
+		INSERT INTO drugs (id, molecule) VALUES ('DB00001', 'Lepirudin');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err := NewSQLiteSink(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteSink() on an old database: %v", err)
+	}
+	defer sink.Close()
+
+	for table, columns := range sqliteAddedColumns {
+		existing := make(map[string]bool)
+		rows, err := sink.db.Query("SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var name string
+			rows.Scan(&name)
+			existing[name] = true
+		}
+		rows.Close()
+		for _, column := range columns {
+			if !existing[column[0]] {
+				t.Errorf("%s.%s was not added", table, column[0])
+			}
+		}
+	}
+
+	complete := true
+	drug := DrugInfo{ID: "DB00001", Molecule: "Lepirudin", DrugInteractionsComplete: &complete, DrugCategories: []DrugCategory{{ID: "DBCAT000012", Name: "Anticoagulants"}}}
+	if err := sink.Write(drug); err != nil {
+		t.Fatalf("Write() into the migrated database: %v", err)
+	}
+	var categoryID string
+	if err := sink.db.QueryRow("SELECT category_id FROM drug_categories WHERE drug_id = 'DB00001'").Scan(&categoryID); err != nil || categoryID != "DBCAT000012" {
+		t.Errorf("category_id = %q, %v, want DBCAT000012", categoryID, err)
+	}
+}
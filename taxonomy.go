@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ChemicalTaxonomy is the ClassyFire classification listed in the Chemical Taxonomy block.
+type ChemicalTaxonomy struct {
+	Description         string   `json:"description,omitempty"`
+	Kingdom             string   `json:"kingdom,omitempty"`
+	SuperClass          string   `json:"super_class,omitempty"`
+	Class               string   `json:"class,omitempty"`
+	SubClass            string   `json:"sub_class,omitempty"`
+	DirectParent        string   `json:"direct_parent,omitempty"`
+	AlternativeParents  []string `json:"alternative_parents,omitempty"`
+	Substituents        []string `json:"substituents,omitempty"`
+	MolecularFramework  string   `json:"molecular_framework,omitempty"`
+	ExternalDescriptors []string `json:"external_descriptors,omitempty"`
+}
+
+// isTaxonomyBlock reports whether the dt belongs to the Chemical Taxonomy block, whose
+// "Description" would otherwise overwrite the drug's own description.
+func isTaxonomyBlock(dt *goquery.Selection) bool {
+	found := false
+	dt.Parent().ChildrenFiltered("dt").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		found = normalize(s.Text()) == "kingdom"
+		return !found
+	})
+	return found
+}
+
+// taxonomyTerms splits a list of taxonomy terms, linked one by one or separated by slashes.
+func taxonomyTerms(dd *goquery.Selection) []string {
+	var terms []string
+	if links := dd.Find("a"); links.Length() > 0 {
+		links.Each(func(_ int, a *goquery.Selection) {
+			if term := normalizeFieldValue("", a.Text()); term != "" {
+				terms = append(terms, term)
+			}
+		})
+		return terms
+	}
+
+	separator := " / "
+	if dd.Find("li").Length() > 0 {
+		dd.Find("li").Each(func(_ int, li *goquery.Selection) {
+			terms = append(terms, normalizeFieldValue("", li.Text()))
+		})
+		return terms
+	} else if !strings.Contains(dd.Text(), separator) {
+		separator = "\n"
+	}
+	for _, term := range strings.Split(dd.Text(), separator) {
+		if term = normalizeFieldValue("", term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func handleChemicalTaxonomy(sibling *goquery.Selection, fieldPtr interface{}, name string) error {
+	json, ok := fieldPtr.(*DrugInfo)
+	if !ok {
+		return fmt.Errorf("handleChemicalTaxonomy(): type assertion to *DrugInfo failed")
+	}
+
+	if sibling == nil || json == nil {
+		return fmt.Errorf("handleChemicalTaxonomy(): invalid arguments")
+	}
+
+	if json.Taxonomy == nil {
+		json.Taxonomy = &ChemicalTaxonomy{}
+	}
+	taxonomy := json.Taxonomy
+	text := normalizeFieldValue("", sibling.Text())
+
+	switch name {
+	case "TaxonomyDescription":
+		taxonomy.Description = text
+	case "Kingdom":
+		taxonomy.Kingdom = text
+	case "SuperClass":
+		taxonomy.SuperClass = text
+	case "Class":
+		taxonomy.Class = text
+	case "SubClass":
+		taxonomy.SubClass = text
+	case "DirectParent":
+		taxonomy.DirectParent = text
+	case "MolecularFramework":
+		taxonomy.MolecularFramework = text
+	case "AlternativeParents":
+		taxonomy.AlternativeParents = taxonomyTerms(sibling)
+	case "Substituents":
+		taxonomy.Substituents = taxonomyTerms(sibling)
+	case "ExternalDescriptors":
+		taxonomy.ExternalDescriptors = taxonomyTerms(sibling)
+	default:
+		return fmt.Errorf("handleChemicalTaxonomy(): unknown taxonomy field '%s'", name)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsTaxonomyBlock(t *testing.T) {
+	page := fixture(t, `
+		<dl id="identification"><dt>Description</dt><dd>Aspirin is ...</dd><dt>Type</dt><dd>Small Molecule</dd></dl>
+		<dl id="taxonomy"><dt>Description</dt><dd>This compound belongs to ...</dd><dt>Kingdom</dt><dd>Organic compounds</dd></dl>`)
+
+	if isTaxonomyBlock(page.Find("#identification dt").First()) {
+		t.Error("the identification description was taken for the taxonomy block")
+	}
+	if !isTaxonomyBlock(page.Find("#taxonomy dt").First()) {
+		t.Error("the taxonomy description wasn't recognised")
+	}
+}
+
+func TestTaxonomyTerms(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<dd><a href="#">Acylsalicylic acids</a><a href="#">Phenoxy compounds</a><a href="#"> </a></dd>`, []string{"Acylsalicylic acids", "Phenoxy compounds"}},
+		{`<dd><ul><li>Benzoyl</li><li>Phenol ester</li></ul></dd>`, []string{"Benzoyl", "Phenol ester"}},
+		{`<dd>Acylsalicylic acid / Benzoic acid / Carboxylic acid ester</dd>`, []string{"Acylsalicylic acid", "Benzoic acid", "Carboxylic acid ester"}},
+		{"<dd>Aromatic homomonocyclic compounds\n  Carboxylic acids\n</dd>", []string{"Aromatic homomonocyclic compounds", "Carboxylic acids"}},
+		{`<dd></dd>`, nil},
+	}
+	for _, tt := range tests {
+		page := fixture(t, "<dl><dt>Substituents</dt>"+tt.html+"</dl>")
+		if got := taxonomyTerms(page.Find("dd")); !slices.Equal(got, tt.want) {
+			t.Errorf("taxonomyTerms(%s) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestHandleChemicalTaxonomy(t *testing.T) {
+	page := fixture(t, `<dl><dt>Kingdom</dt><dd> Organic
+		compounds </dd><dt>Substituents</dt><dd>Benzoyl / Phenol ester</dd></dl>`)
+
+	var drugInfo DrugInfo
+	if err := handleChemicalTaxonomy(page.Find("dd").Eq(0), &drugInfo, "Kingdom"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handleChemicalTaxonomy(page.Find("dd").Eq(1), &drugInfo, "Substituents"); err != nil {
+		t.Fatal(err)
+	}
+	if drugInfo.Taxonomy.Kingdom != "Organic compounds" || !slices.Equal(drugInfo.Taxonomy.Substituents, []string{"Benzoyl", "Phenol ester"}) {
+		t.Errorf("taxonomy = %+v", *drugInfo.Taxonomy)
+	}
+	if err := handleChemicalTaxonomy(page.Find("dd").Eq(0), &drugInfo, "Phylum"); err == nil {
+		t.Error("handleChemicalTaxonomy() accepted an unknown field")
+	}
+}